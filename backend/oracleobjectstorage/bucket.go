@@ -0,0 +1,98 @@
+//go:build !plan9 && !solaris && !js
+// +build !plan9,!solaris,!js
+
+package oracleobjectstorage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// bucketCreateDetails makes the details to create bucketName with from
+// the options of the bucket-create backend command
+func (f *Fs) bucketCreateDetails(bucketName string, opt map[string]string) (details objectstorage.CreateBucketDetails, err error) {
+	compartment := opt["compartment"]
+	if compartment == "" {
+		compartment = f.opt.Compartment
+	}
+	if compartment == "" {
+		return details, fmt.Errorf("bucket-create needs a compartment, set it in the config or with -o compartment=OCID")
+	}
+	err = checkCompartment(compartment)
+	if err != nil {
+		return details, err
+	}
+	details = objectstorage.CreateBucketDetails{
+		Name:             common.String(bucketName),
+		CompartmentId:    common.String(compartment),
+		PublicAccessType: objectstorage.CreateBucketDetailsPublicAccessTypeNopublicaccess,
+	}
+	var ok bool
+	if value := opt["public-access"]; value != "" {
+		details.PublicAccessType, ok = objectstorage.GetMappingCreateBucketDetailsPublicAccessTypeEnum(value)
+		if !ok {
+			return details, fmt.Errorf("bad public-access %q: it should be one of %v", value, objectstorage.GetCreateBucketDetailsPublicAccessTypeEnumStringValues())
+		}
+	}
+	if value := opt["storage-tier"]; value != "" {
+		details.StorageTier, ok = objectstorage.GetMappingCreateBucketDetailsStorageTierEnum(value)
+		if !ok {
+			return details, fmt.Errorf("bad storage-tier %q: it should be one of %v", value, objectstorage.GetCreateBucketDetailsStorageTierEnumStringValues())
+		}
+	}
+	autoTiering := opt["auto-tiering"]
+	if autoTiering == "" {
+		autoTiering = f.opt.BucketAutoTiering
+	}
+	details.AutoTiering, ok = objectstorage.GetMappingBucketAutoTieringEnum(autoTiering)
+	if !ok {
+		return details, fmt.Errorf("bad auto-tiering %q: it should be one of %v", autoTiering, objectstorage.GetBucketAutoTieringEnumStringValues())
+	}
+	if _, ok := opt["versioning"]; ok {
+		details.Versioning = objectstorage.CreateBucketDetailsVersioningEnabled
+	}
+	if _, ok := opt["object-events"]; ok {
+		details.ObjectEventsEnabled = common.Bool(true)
+	}
+	if value := opt["kms-key-id"]; value != "" {
+		details.KmsKeyId = common.String(value)
+	}
+	return details, nil
+}
+
+// bucketCreate creates bucketName with the options of the
+// bucket-create backend command and returns its details.
+//
+// Unlike Mkdir it is an error if the bucket exists already.
+func (f *Fs) bucketCreate(ctx context.Context, bucketName string, opt map[string]string) (*bucketInfo, error) {
+	details, err := f.bucketCreateDetails(bucketName, opt)
+	if err != nil {
+		return nil, err
+	}
+	if operations.SkipDestructive(ctx, bucketName, "create bucket") {
+		return nil, nil
+	}
+	bucket, err := f.createBucket(ctx, details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bucket %q: %w", bucketName, err)
+	}
+	f.cache.MarkOK(bucketName)
+	return newBucketInfo(bucket), nil
+}
+
+// bucketDelete deletes bucketName, which must be empty, for the
+// bucket-delete backend command
+func (f *Fs) bucketDelete(ctx context.Context, bucketName string) error {
+	if operations.SkipDestructive(ctx, bucketName, "delete bucket") {
+		return nil
+	}
+	err := f.deleteBucket(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("failed to delete bucket %q: %w", bucketName, err)
+	}
+	return nil
+}
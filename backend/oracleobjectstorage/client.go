@@ -6,22 +6,33 @@ package oracleobjectstorage
 import (
 	"context"
 	"crypto/rsa"
+	"crypto/tls"
 	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/oracle/oci-go-sdk/v65/common"
 	"github.com/oracle/oci-go-sdk/v65/common/auth"
 	"github.com/oracle/oci-go-sdk/v65/objectstorage"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/fserrors"
 	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/lib/pacer"
 )
 
-func getConfigurationProvider(opt *Options) (common.ConfigurationProvider, error) {
+func getConfigurationProvider(ctx context.Context, opt *Options) (common.ConfigurationProvider, error) {
 	switch opt.Provider {
 	case instancePrincipal:
-		return auth.InstancePrincipalConfigurationProvider()
+		// make the calls to fetch the instance certificates and security
+		// tokens with rclone's http client so --ca-cert etc are honored
+		return auth.InstancePrincipalConfigurationProviderWithCustomClient(imdsDispatcherModifier(ctx))
 	case userPrincipal:
 		if opt.ConfigFile != "" && !fileExists(opt.ConfigFile) {
 			fs.Errorf(userPrincipal, "oci config file doesn't exist at %v", opt.ConfigFile)
@@ -38,7 +49,7 @@ func getConfigurationProvider(opt *Options) (common.ConfigurationProvider, error
 }
 
 func newObjectStorageClient(ctx context.Context, opt *Options) (*objectstorage.ObjectStorageClient, error) {
-	p, err := getConfigurationProvider(opt)
+	p, err := getConfigurationProvider(ctx, opt)
 	if err != nil {
 		return nil, err
 	}
@@ -50,10 +61,78 @@ func newObjectStorageClient(ctx context.Context, opt *Options) (*objectstorage.O
 	if opt.Region != "" {
 		client.SetRegion(opt.Region)
 	}
+	if endpoint := getEndpoint(opt); endpoint != "" {
+		// every call, including multipart uploads and copies, is made
+		// with this client so this overrides the host the SDK picks
+		// for the region, which is in the commercial realm for
+		// regions it doesn't know
+		client.Host = endpoint
+	}
 	modifyClient(ctx, opt, &client.BaseClient)
+	if opt.MaxRetries > 0 {
+		// the SDK retries some operations itself with long sleeps
+		// in between, so leave all the retries to the pacer
+		noRetry := common.NoRetryPolicy()
+		client.Configuration.RetryPolicy = &noRetry
+	}
+	client.Interceptor = addExtraHeaders
+	if opt.ClientRequestIDPrefix != "" {
+		client.Interceptor = func(req *http.Request) error {
+			req.Header.Set(clientRequestIDHeader, opt.ClientRequestIDPrefix+"-"+uuid.New().String())
+			return addExtraHeaders(req)
+		}
+	}
 	return &client, err
 }
 
+// clientRequestIDHeader is the header with the client's ID for a
+// request, which object storage returns with the response
+const clientRequestIDHeader = "opc-client-request-id"
+
+// extraHeadersKey is the context key for the headers added by
+// addExtraHeaders
+type extraHeadersKey struct{}
+
+// withExtraHeaders returns a context which makes requests made with it
+// send headers the SDK doesn't have fields for
+func withExtraHeaders(ctx context.Context, headers map[string]string) context.Context {
+	return context.WithValue(ctx, extraHeadersKey{}, headers)
+}
+
+// addExtraHeaders is the client's interceptor which adds the headers
+// set on the request's context by withExtraHeaders
+func addExtraHeaders(req *http.Request) error {
+	headers, _ := req.Context().Value(extraHeadersKey{}).(map[string]string)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return nil
+}
+
+// getEndpoint returns the endpoint from the config as a URL, adding
+// the https scheme to a bare host name such as a private endpoint or
+// service gateway host.
+//
+// If no endpoint is configured it returns the default endpoint of the
+// region in its realm, or the realm specific endpoint of the namespace
+// if that is enabled, or "" if no region is configured either.
+func getEndpoint(opt *Options) string {
+	endpoint := strings.TrimRight(strings.TrimSpace(opt.Endpoint), "/")
+	if endpoint == "" {
+		if useRealmSpecificEndpoint(opt) {
+			if endpoint := realmSpecificEndpoint(opt.Namespace, opt.Region); endpoint != "" {
+				return endpoint
+			}
+			fs.Logf(nil, "No realm specific endpoint is known for region %q, using the default endpoint", opt.Region)
+		}
+		return regionEndpoint(opt.Region)
+	}
+	if !strings.HasPrefix(endpoint, "https://") && !strings.HasPrefix(endpoint, "http://") {
+		endpoint = "https://" + endpoint
+	}
+	return endpoint
+}
+
 func fileExists(filePath string) bool {
 	if _, err := os.Stat(filePath); errors.Is(err, os.ErrNotExist) {
 		return false
@@ -61,8 +140,11 @@ func fileExists(filePath string) bool {
 	return true
 }
 
+// modifyClient makes the SDK client send all its requests through
+// rclone's http transport so the global flags such as --bwlimit,
+// --timeout, --contimeout, --user-agent and --dump are honored.
 func modifyClient(ctx context.Context, opt *Options, client *common.BaseClient) {
-	client.HTTPClient = getHTTPClient(ctx)
+	client.HTTPClient = newHTTPClient(ctx, opt)
 	if opt.Provider == noAuth {
 		client.Signer = getNoAuthSigner()
 	}
@@ -74,6 +156,78 @@ func getHTTPClient(ctx context.Context) *http.Client {
 	return fshttp.NewClient(ctx)
 }
 
+// newHTTPClient returns the http client for object storage requests,
+// which only uses HTTP/1.1 if disable_http2 is set
+func newHTTPClient(ctx context.Context, opt *Options) *http.Client {
+	client := getHTTPClient(ctx)
+	if opt.DisableHTTP2 {
+		// the shared transport is left alone so only this backend
+		// is affected
+		client.Transport = fshttp.NewTransportCustom(ctx, func(t *http.Transport) {
+			t.ForceAttemptHTTP2 = false
+			t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		})
+	}
+	return client
+}
+
+// httpDispatcherModifier returns a modifier for the SDK auth clients
+// which replaces their default http client with rclone's
+func httpDispatcherModifier(ctx context.Context) func(common.HTTPRequestDispatcher) (common.HTTPRequestDispatcher, error) {
+	return func(common.HTTPRequestDispatcher) (common.HTTPRequestDispatcher, error) {
+		return getHTTPClient(ctx), nil
+	}
+}
+
+// imdsHost is the address of the instance metadata service
+var imdsHost = "169.254.169.254"
+
+// imdsTimeout is how long a request to the instance metadata service
+// may take, which is short as it is local to the instance
+const imdsTimeout = 10 * time.Second
+
+// imdsDispatcher sends the requests of the instance principal provider
+// with rclone's http client, making sure those to the instance metadata
+// service use the v2 endpoints with their token header.
+//
+// Hardened instances only serve IMDSv2 and the SDK falls back to v1 if
+// it can't read the region from v2, so v1 requests are sent to v2
+// instead. The metadata requests have a short timeout so an instance
+// with the service disabled gives a clear error rather than hanging.
+type imdsDispatcher struct {
+	dispatcher common.HTTPRequestDispatcher // for everything else
+	imds       common.HTTPRequestDispatcher // for the metadata service
+}
+
+// Do sends req
+func (d imdsDispatcher) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != imdsHost {
+		return d.dispatcher.Do(req)
+	}
+	if v1Path := strings.TrimPrefix(req.URL.Path, "/opc/v1/"); v1Path != req.URL.Path {
+		req.URL.Path = "/opc/v2/" + v1Path
+	}
+	req.Header.Set("Authorization", "Bearer Oracle")
+	resp, err := d.imds.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("instance metadata service isn't reachable at %s, instance_principal_auth only works on OCI instances with IMDSv2 enabled: %w", imdsHost, err)
+	}
+	return resp, nil
+}
+
+// imdsDispatcherModifier returns a modifier for the instance principal
+// auth client which sends its requests with an imdsDispatcher
+func imdsDispatcherModifier(ctx context.Context) func(common.HTTPRequestDispatcher) (common.HTTPRequestDispatcher, error) {
+	return func(common.HTTPRequestDispatcher) (common.HTTPRequestDispatcher, error) {
+		imdsClient := *getHTTPClient(ctx)
+		imdsClient.Timeout = imdsTimeout
+		return imdsDispatcher{
+			dispatcher: getHTTPClient(ctx),
+			imds:       &imdsClient,
+		}, nil
+	}
+}
+
 var retryErrorCodes = []int{
 	408, // Request Timeout
 	429, // Rate exceeded.
@@ -86,19 +240,250 @@ func shouldRetry(ctx context.Context, resp *http.Response, err error) (bool, err
 	if fserrors.ContextError(ctx, &err) {
 		return false, err
 	}
+	err = errorWithHostHint(err, resp)
 	// If this is an ocierr object, try and extract more useful information to determine if we should retry
 	if ociError, ok := err.(common.ServiceError); ok {
 		// Simple case, check the original embedded error in case it's generically retryable
 		if fserrors.ShouldRetry(err) {
-			return true, err
+			return true, errorWithRequestID(err, resp)
 		}
 		// If it is a timeout then we want to retry that
 		if ociError.GetCode() == "RequestTimeout" {
-			return true, err
+			return true, errorWithRequestID(err, resp)
 		}
 	}
+	if isConnectionError(err) {
+		return true, errorWithRequestID(err, resp)
+	}
 	// Ok, not an oci error, check for generic failure conditions
-	return fserrors.ShouldRetry(err) || fserrors.ShouldRetryHTTP(resp, retryErrorCodes), err
+	return fserrors.ShouldRetry(err) || fserrors.ShouldRetryHTTP(resp, retryErrorCodes), errorWithRequestID(err, resp)
+}
+
+// isContentMD5Mismatch returns true if err is object storage rejecting
+// an upload as its body didn't match the Content-MD5 header sent
+func isContentMD5Mismatch(err error) bool {
+	var serviceErr common.ServiceError
+	if !errors.As(err, &serviceErr) || serviceErr.GetHTTPStatusCode() != http.StatusBadRequest {
+		return false
+	}
+	return serviceErr.GetCode() == "UnmatchedContentMD5" ||
+		strings.Contains(serviceErr.GetMessage(), "does not match the Content-MD5 header")
+}
+
+// isNotAuthorized returns true if err is object storage refusing a
+// request for lack of permissions. Object storage doesn't distinguish
+// this from the resource not existing when it returns 404
+// NotAuthorizedOrNotFound.
+func isNotAuthorized(err error) bool {
+	var serviceErr common.ServiceError
+	if !errors.As(err, &serviceErr) {
+		return false
+	}
+	switch serviceErr.GetHTTPStatusCode() {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return true
+	case http.StatusNotFound:
+		return serviceErr.GetCode() == "NotAuthorizedOrNotFound"
+	}
+	return false
+}
+
+// isCopyRejected returns true if err is object storage refusing to
+// start a server-side copy, so it can be done by downloading and
+// uploading instead
+func isCopyRejected(err error) bool {
+	var serviceErr common.ServiceError
+	return errors.As(err, &serviceErr) && serviceErr.GetHTTPStatusCode() == http.StatusBadRequest
+}
+
+// serviceErrorWithHint is a common.ServiceError with a hint about how
+// to fix it. It embeds the original error so callers can still type
+// assert it to common.ServiceError.
+type serviceErrorWithHint struct {
+	common.ServiceError
+	err  error
+	hint string
+}
+
+// Error returns the hint followed by the service error
+func (e serviceErrorWithHint) Error() string {
+	return fmt.Sprintf("%s: %v", e.hint, e.err)
+}
+
+// Unwrap returns the original service error
+func (e serviceErrorWithHint) Unwrap() error {
+	return e.err
+}
+
+// errorWithHostHint adds a hint to check the namespace and region to
+// the errors returned when the requests are sent to the wrong host,
+// which is usually because the namespace or region was copied from
+// another tenancy. Other errors are returned unchanged.
+func errorWithHostHint(err error, resp *http.Response) error {
+	if err == nil {
+		return nil
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return fmt.Errorf("host %q not found, check the region or endpoint is right: %w", dnsErr.Name, err)
+	}
+	var serviceErr common.ServiceError
+	if !errors.As(err, &serviceErr) || serviceErr.GetHTTPStatusCode() != http.StatusNotFound {
+		return err
+	}
+	if serviceErr.GetCode() != "NamespaceNotFound" && !strings.Contains(strings.ToLower(serviceErr.GetMessage()), "namespace does not match") {
+		return err
+	}
+	namespace, host := "", ""
+	if resp != nil && resp.Request != nil && resp.Request.URL != nil {
+		host = resp.Request.URL.Host
+		if parts := strings.SplitN(strings.TrimPrefix(resp.Request.URL.Path, "/"), "/", 3); len(parts) >= 2 && parts[0] == "n" {
+			namespace = parts[1]
+		}
+	}
+	hint := fmt.Sprintf("namespace %q not found at %q, check the namespace and the region or endpoint are from the same tenancy", namespace, host)
+	return serviceErrorWithHint{ServiceError: serviceErr, err: err, hint: hint}
+}
+
+// isWrongHost returns true if err has the hint added by
+// errorWithHostHint, so it shouldn't be treated as the object or
+// directory not existing
+func isWrongHost(err error) bool {
+	var hintErr serviceErrorWithHint
+	return errors.As(err, &hintErr)
+}
+
+// shouldRetryNotIdempotent is shouldRetry for calls which may have
+// been applied if the connection failed before the response was read,
+// such as committing a multipart upload.
+//
+// These are only retried if the server responded saying it didn't
+// process the request.
+func shouldRetryNotIdempotent(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if fserrors.ContextError(ctx, &err) {
+		return false, err
+	}
+	err = errorWithHostHint(err, resp)
+	return fserrors.ShouldRetryHTTP(resp, notIdempotentRetryErrorCodes), errorWithRequestID(err, resp)
+}
+
+// requestContext returns the context for one attempt at a request
+// which doesn't transfer object data, which is cancelled after
+// --oos-request-timeout. cancel must be called when the attempt is done.
+func (f *Fs) requestContext(ctx context.Context) (reqCtx context.Context, cancel context.CancelFunc) {
+	if f.opt.RequestTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, time.Duration(f.opt.RequestTimeout))
+}
+
+// chunkContext returns the context for one attempt at uploading a part
+// of a multipart upload, which is cancelled after --oos-chunk-timeout.
+// cancel must be called when the attempt is done.
+func (f *Fs) chunkContext(ctx context.Context) (chunkCtx context.Context, cancel context.CancelFunc) {
+	if f.opt.ChunkTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, time.Duration(f.opt.ChunkTimeout))
+}
+
+// logRetries wraps the paced function fn doing operation so that each
+// retry is logged at INFO level with the attempt number and the reason,
+// so retries are visible with -v without needing -vv.
+func logRetries(operation string, fn pacer.Paced) pacer.Paced {
+	attempt := 0
+	return func() (bool, error) {
+		attempt++
+		retry, err := fn()
+		if retry && err != nil {
+			fs.Infof(nil, "oos: %s: retrying after attempt %d: %s", operation, attempt, retryReason(err))
+		}
+		return retry, err
+	}
+}
+
+// retryReason returns a one line description of why err is retried
+func retryReason(err error) string {
+	var serviceErr common.ServiceError
+	if errors.As(err, &serviceErr) {
+		return fmt.Sprintf("%d %s", serviceErr.GetHTTPStatusCode(), serviceErr.GetCode())
+	}
+	reason := err.Error()
+	if i := strings.IndexByte(reason, '\n'); i >= 0 {
+		reason = reason[:i]
+	}
+	return reason
+}
+
+// status codes which mean the server didn't process the request
+var notIdempotentRetryErrorCodes = []int{
+	429, // Rate exceeded.
+	503, // Service Unavailable
+}
+
+// isConnectionError returns true if err is a connection failing part
+// way through a request: an unexpected EOF, a network timeout or the
+// connection being reset or aborted.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// serviceErrorWithIDs is a common.ServiceError annotated with the
+// request ids of the failing response. It embeds the original error
+// so callers can still type assert it to common.ServiceError.
+type serviceErrorWithIDs struct {
+	common.ServiceError
+	err error
+	ids string
+}
+
+// Error returns the service error with the request ids appended
+func (e serviceErrorWithIDs) Error() string {
+	return fmt.Sprintf("%v (%s)", e.err, e.ids)
+}
+
+// Unwrap returns the original service error
+func (e serviceErrorWithIDs) Unwrap() error {
+	return e.err
+}
+
+// errorWithRequestID appends the opc-request-id and opc-client-request-id
+// of resp to err so they can be quoted to Oracle support. Ids which
+// already appear in the error message aren't repeated.
+func errorWithRequestID(err error, resp *http.Response) error {
+	if err == nil || resp == nil {
+		return err
+	}
+	requestID := resp.Header.Get("opc-request-id")
+	clientRequestID := resp.Header.Get(clientRequestIDHeader)
+	if clientRequestID == "" && resp.Request != nil {
+		clientRequestID = resp.Request.Header.Get(clientRequestIDHeader)
+	}
+	msg := err.Error()
+	var ids []string
+	if requestID != "" && !strings.Contains(msg, requestID) {
+		ids = append(ids, "opc-request-id: "+requestID)
+	}
+	if clientRequestID != "" && !strings.Contains(msg, clientRequestID) {
+		ids = append(ids, "opc-client-request-id: "+clientRequestID)
+	}
+	if len(ids) == 0 {
+		return err
+	}
+	if svcErr, ok := err.(common.ServiceError); ok {
+		return serviceErrorWithIDs{ServiceError: svcErr, err: err, ids: strings.Join(ids, ", ")}
+	}
+	return fmt.Errorf("%w (%s)", err, strings.Join(ids, ", "))
 }
 
 func getNoAuthConfiguration() (common.ConfigurationProvider, error) {
@@ -5,7 +5,11 @@ package oracleobjectstorage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,6 +26,21 @@ const (
 	operationRename        = "rename"
 	operationListMultiPart = "list-multipart-uploads"
 	operationCleanup       = "cleanup"
+	operationRetention     = "retention"
+	operationAutoTiering   = "auto-tiering"
+	operationBucketInfo    = "bucket-info"
+	operationReplication   = "replication"
+	operationUsageByTier   = "usage-by-tier"
+	operationParCreate     = "par-create"
+	operationParList       = "par-list"
+	operationParDelete     = "par-delete"
+	operationLegalHold     = "legal-hold"
+	operationListBuckets   = "list-buckets"
+	operationSetMetadata   = "set-metadata"
+	operationList          = "list"
+	operationObjectHead    = "object-head"
+	operationBucketCreate  = "bucket-create"
+	operationBucketDelete  = "bucket-delete"
 )
 
 var commandHelp = []fs.CommandHelp{{
@@ -32,8 +51,18 @@ var commandHelp = []fs.CommandHelp{{
 Usage Examples:
 
     rclone backend rename oos:bucket relative-object-path-under-bucket object-new-name
+
+The object is renamed by object storage so it keeps its storage tier,
+metadata and ETag.
+
+It is an error if an object called object-new-name exists already
+unless the overwrite option is given, in which case it is replaced:
+
+    rclone backend rename oos:bucket old-name new-name -o overwrite
 `,
-	Opts: nil,
+	Opts: map[string]string{
+		"overwrite": "Replace the object with the new name if it exists",
+	},
 }, {
 	Name:  operationListMultiPart,
 	Short: "List the unfinished multipart uploads",
@@ -76,6 +105,334 @@ Durations are parsed as per the rest of rclone, 2h, 7d, 7w etc.
 	Opts: map[string]string{
 		"max-age": "Max age of upload to delete",
 	},
+}, {
+	Name:  operationRetention,
+	Short: "List the retention rules on a bucket",
+	Long: `This command lists the retention rules of a bucket in JSON format.
+
+    rclone backend retention oos:bucket
+
+Objects covered by a retention rule can't be overwritten or deleted
+until the rule's duration has passed since they were last modified, or
+ever if the rule has no duration.
+`,
+}, {
+	Name:  operationAutoTiering,
+	Short: "Show the auto-tiering of a bucket",
+	Long: `This command shows whether auto-tiering is enabled on a bucket.
+
+    rclone backend auto-tiering oos:bucket
+
+It returns "Disabled" or "InfrequentAccess". Use the bucket_auto_tiering
+option to enable auto-tiering on buckets rclone creates.
+`,
+}, {
+	Name:  operationBucketInfo,
+	Short: "Show the details of a bucket",
+	Long: `This command shows the details of a bucket in JSON format.
+
+    rclone backend bucket-info oos:bucket
+
+It returns the namespace, compartment, public access type, default
+storage tier, versioning, auto-tiering, approximate number and size of
+the objects and whether replication is enabled, for example
+
+    {
+        "name": "bucket",
+        "namespace": "test-namespace",
+        "compartmentId": "ocid1.compartment.oc1..aaaaaaaa",
+        "publicAccessType": "NoPublicAccess",
+        "storageTier": "Standard",
+        "versioning": "Disabled",
+        "autoTiering": "Disabled",
+        "approximateCount": 1234,
+        "approximateSize": 56789012,
+        "replicationEnabled": false,
+        "isReadOnly": false
+    }
+
+The object count and size are updated periodically by object storage
+so may not include recent changes.
+`,
+}, {
+	Name:  operationReplication,
+	Short: "List or create the replication policies of a bucket",
+	Long: `This command lists the replication policies of a bucket in JSON format.
+
+    rclone backend replication oos:bucket
+
+With the create argument it adds a replication policy to the bucket
+which replicates it to the destination bucket in the destination
+region.
+
+    rclone backend replication oos:bucket create -o destination-region=us-phoenix-1 -o destination-bucket=bucket-dr -o confirm
+
+As object storage makes the destination bucket read only, the policy
+is only created with the confirm option. Use -i/--dry-run to see what
+it would do.
+`,
+	Opts: map[string]string{
+		"destination-region": "Region to replicate to",
+		"destination-bucket": "Bucket to replicate to",
+		"name":               "Name of the policy, defaults to one made from the buckets and region",
+		"confirm":            "Confirm the policy should be created",
+	},
+}, {
+	Name:  operationUsageByTier,
+	Short: "Show the number and size of objects in each storage tier",
+	Long: `This command lists the objects under the path given and shows the
+number and total size of them in each storage tier and archival state
+in JSON format.
+
+    rclone backend usage-by-tier oos:bucket/path/to/dir
+
+It reads the tiers from the listing without a HEAD request per object
+and doesn't change anything, for example
+
+    [
+        {
+            "tier": "Archive",
+            "archivalState": "Archived",
+            "count": 120,
+            "size": 52428800000
+        },
+        {
+            "tier": "Standard",
+            "count": 4567,
+            "size": 1234567890
+        }
+    ]
+
+The list_fields option must include storageTier and archivalState.
+`,
+}, {
+	Name:  operationParCreate,
+	Short: "Create a pre-authenticated request for an object",
+	Long: `This command creates a pre-authenticated request (PAR) for an object
+so it can be read or uploaded without credentials, and shows its URL
+and expiry in JSON format.
+
+    rclone backend par-create oos:bucket/path/to/object -o access=write -o expire=24h
+
+The object can also be given as an argument relative to the remote.
+
+    rclone backend par-create oos:bucket path/to/object
+
+The object doesn't need to exist for write access, so this can be used
+to let an external system upload the object with a PUT to the URL,
+for example
+
+    {
+        "id": "aBcD...",
+        "name": "rclone-path/to/object-20240101T000000Z",
+        "object": "path/to/object",
+        "accessType": "ObjectWrite",
+        "url": "https://objectstorage.us-ashburn-1.oraclecloud.com/p/aBcD.../n/test-namespace/b/bucket/o/path/to/object",
+        "expires": "2024-01-02T00:00:00Z"
+    }
+
+Anyone with the URL can use it until it expires, so keep it secret.
+`,
+	Opts: map[string]string{
+		"access": "One of read, write or readwrite, defaults to write",
+		"expire": "How long the PAR lasts, defaults to 24h",
+		"name":   "Name of the PAR, defaults to one made from the object and time",
+	},
+}, {
+	Name:  operationParList,
+	Short: "List the pre-authenticated requests of a bucket",
+	Long: `This command lists the pre-authenticated requests (PARs) of a bucket
+in JSON format.
+
+    rclone backend par-list oos:bucket
+    rclone backend par-list oos:bucket/path/to/dir
+
+With a path it only lists the PARs for objects starting with it. The
+URLs of PARs are only shown when they are created, for example
+
+    [
+        {
+            "id": "aBcD...",
+            "name": "upload",
+            "object": "path/to/object",
+            "accessType": "ObjectWrite",
+            "expires": "2024-01-02T00:00:00Z"
+        }
+    ]
+`,
+}, {
+	Name:  operationParDelete,
+	Short: "Delete a pre-authenticated request",
+	Long: `This command deletes a pre-authenticated request (PAR) of a bucket
+so its URL can no longer be used.
+
+    rclone backend par-delete oos:bucket -o id=PAR_ID
+
+The id is shown by par-create and par-list. It isn't an error if the
+PAR has already been deleted. Use -i/--dry-run to see what it would do.
+`,
+	Opts: map[string]string{
+		"id": "Id of the PAR to delete",
+	},
+}, {
+	Name:  operationLegalHold,
+	Short: "Show whether an object is under a legal hold",
+	Long: `This command shows whether an object is held by the retention rules of
+its bucket in JSON format.
+
+    rclone backend legal-hold oos:bucket path/to/object
+
+OCI Object Storage has no legal holds on single objects. Instead a
+retention rule without a duration holds every object in the bucket
+until the rule is deleted, so this reports the object as under a legal
+hold if the bucket has such a rule, with the names of the rules. If
+the object is only retained by rules with a duration it shows when it
+can next be changed or deleted, for example
+
+    {
+        "bucket": "bucket",
+        "object": "path/to/object",
+        "legalHold": true,
+        "holdRules": ["litigation"]
+    }
+
+The on and off options give an error saying this isn't supported.
+Use the OCI console or CLI to add or delete the retention rules of the
+bucket instead.
+`,
+	Opts: map[string]string{
+		"on":  "Set a legal hold on the object, which isn't supported",
+		"off": "Clear the legal hold on the object, which isn't supported",
+	},
+}, {
+	Name:  operationListBuckets,
+	Short: "List the buckets in a compartment",
+	Long: `This command lists the names of the buckets in the configured
+compartment, or in the compartment given with the compartment option
+without changing the config.
+
+    rclone backend list-buckets oos: -o compartment=ocid1.compartment.oc1..xyz
+
+Bucket names are unique in a namespace so once listed the buckets can
+be used as usual whichever compartment they are in. To create buckets
+in another compartment override the compartment in the remote, eg
+
+    rclone mkdir "oos,compartment='ocid1.compartment.oc1..xyz':new-bucket"
+`,
+	Opts: map[string]string{
+		"compartment": "OCID of the compartment to list, defaults to the configured one",
+	},
+}, {
+	Name:  operationSetMetadata,
+	Short: "Set the metadata of objects without uploading them again",
+	Long: `This command sets the content headers or user metadata of the objects
+under the path given, keeping their data and storage tier.
+
+    rclone backend set-metadata oos:bucket/path/to/dir -o content-type=text/html -o cache-control="max-age=3600"
+
+Any other keys set user metadata. The metadata which isn't given is
+kept. Object storage can't change the metadata of an object so each
+one is copied server-side to itself, --transfers at once.
+
+Objects in the Archive tier can't be copied so are skipped with a
+warning. It shows how many objects were updated and which were
+skipped in JSON format. Use -i/--dry-run to see what it would do.
+`,
+	Opts: map[string]string{
+		"cache-control":       "Cache-Control header to set",
+		"content-disposition": "Content-Disposition header to set",
+		"content-encoding":    "Content-Encoding header to set",
+		"content-language":    "Content-Language header to set",
+		"content-type":        "Content-Type header to set",
+	},
+}, {
+	Name:  operationList,
+	Short: "List one page of objects from a start name",
+	Long: `This command lists one page of the objects under the path given,
+recursively, in JSON format with the name to start the next page from.
+
+    rclone backend list oos:bucket/path/to/dir -o limit=1000
+
+Pass the nextStart of a page as the start option to get the next one,
+so a listing of a large bucket can be done in steps and resumed.
+
+    rclone backend list oos:bucket/path/to/dir -o limit=1000 -o start=file0999.txt
+
+There are no more pages when nextStart isn't set. The names are
+relative to the path given, for example
+
+    {
+        "objects": [
+            {
+                "name": "file0999.txt",
+                "size": 1234,
+                "timeModified": "2022-07-29T06:21:16.595Z"
+            }
+        ],
+        "nextStart": "file1999.txt"
+    }
+`,
+	Opts: map[string]string{
+		"start": "Name to start listing from, the nextStart of the previous page",
+		"limit": "Maximum number of objects to list, defaults to list_chunk",
+	},
+}, {
+	Name:  operationObjectHead,
+	Short: "Show the headers and metadata of an object",
+	Long: `This command reads the object given with a single HEAD request and
+shows everything object storage returns for it in JSON format. This
+helps to find out why an object isn't synced as expected.
+
+    rclone backend object-head oos:bucket path/to/object
+
+The metadata has the user metadata with its opc-meta- prefix, for
+example
+
+    {
+        "name": "path/to/object",
+        "size": 1234,
+        "etag": "0f5bb5e8-7a2b-4a4b-9ed0-1e2a6e2b1a2c",
+        "contentMd5": "XrY7u+Ae7tCTyyK7j1rNww==",
+        "contentType": "text/plain",
+        "storageTier": "Standard",
+        "lastModified": "2022-07-29T06:21:16Z",
+        "metadata": {
+            "opc-meta-mtime": "1659075676.595"
+        }
+    }
+`,
+}, {
+	Name:  operationBucketCreate,
+	Short: "Create a bucket with the options given",
+	Long: `This command creates a bucket with the OCI specific options given and
+shows its details in JSON format, in the same way as bucket-info.
+
+    rclone backend bucket-create oos:bucket -o public-access=ObjectRead -o versioning
+
+Unlike rclone mkdir, which creates a private bucket the first time it
+is needed, it is an error if the bucket exists already. It is created
+in the configured compartment unless the compartment option is given.
+Use -i/--dry-run to see what it would do.
+`,
+	Opts: map[string]string{
+		"compartment":   "OCID of the compartment to create the bucket in, defaults to the configured one",
+		"public-access": "One of NoPublicAccess, ObjectRead or ObjectReadWithoutList, defaults to NoPublicAccess",
+		"storage-tier":  "Default storage tier of the bucket, Standard or Archive, defaults to Standard",
+		"auto-tiering":  "Disabled or InfrequentAccess, defaults to bucket_auto_tiering",
+		"versioning":    "Enable object versioning",
+		"object-events": "Emit events for object state changes",
+		"kms-key-id":    "OCID of the vault key to encrypt the bucket with",
+	},
+}, {
+	Name:  operationBucketDelete,
+	Short: "Delete an empty bucket",
+	Long: `This command deletes a bucket, which must be empty.
+
+    rclone backend bucket-delete oos:bucket
+
+Use rclone purge to delete a bucket and everything in it. Use
+-i/--dry-run to see what it would do.
+`,
 },
 }
 
@@ -100,7 +457,8 @@ func (f *Fs) Command(ctx context.Context, commandName string, args []string,
 		}
 		remote := args[0]
 		newName := args[1]
-		return f.rename(ctx, remote, newName)
+		_, overwrite := opt["overwrite"]
+		return f.rename(ctx, remote, newName, overwrite)
 	case operationListMultiPart:
 		return f.listMultipartUploadsAll(ctx)
 	case operationCleanup:
@@ -112,12 +470,116 @@ func (f *Fs) Command(ctx context.Context, commandName string, args []string,
 			}
 		}
 		return nil, f.cleanUp(ctx, maxAge)
+	case operationRetention:
+		bucketName, _ := f.split("")
+		if bucketName == "" {
+			return nil, fmt.Errorf("retention needs a bucket, eg oos:bucket")
+		}
+		return f.listRetentionRules(ctx, bucketName)
+	case operationAutoTiering:
+		bucketName, _ := f.split("")
+		if bucketName == "" {
+			return nil, fmt.Errorf("auto-tiering needs a bucket, eg oos:bucket")
+		}
+		return f.getBucketAutoTiering(ctx, bucketName)
+	case operationBucketInfo:
+		bucketName, _ := f.split("")
+		if bucketName == "" {
+			return nil, fmt.Errorf("bucket-info needs a bucket, eg oos:bucket")
+		}
+		return f.getBucketInfo(ctx, bucketName)
+	case operationReplication:
+		bucketName, _ := f.split("")
+		if bucketName == "" {
+			return nil, fmt.Errorf("replication needs a bucket, eg oos:bucket")
+		}
+		if len(args) == 0 {
+			return f.listReplicationPolicies(ctx, bucketName)
+		}
+		if args[0] != "create" {
+			return nil, fmt.Errorf("unknown replication argument %q, only create is supported", args[0])
+		}
+		return f.createReplicationPolicy(ctx, bucketName, opt)
+	case operationUsageByTier:
+		bucketName, directory := f.split("")
+		if bucketName == "" {
+			return nil, fmt.Errorf("usage-by-tier needs a bucket, eg oos:bucket")
+		}
+		return f.usageByTier(ctx, bucketName, directory)
+	case operationParCreate:
+		remote := ""
+		if len(args) > 0 {
+			remote = args[0]
+		}
+		bucketName, bucketPath := f.split(remote)
+		return f.createPar(ctx, bucketName, bucketPath, opt)
+	case operationLegalHold:
+		if len(args) == 0 {
+			return nil, fmt.Errorf("legal-hold needs an object, eg oos:bucket path/to/object")
+		}
+		return f.legalHold(ctx, args[0], opt)
+	case operationParList:
+		bucketName, directory := f.split("")
+		if bucketName == "" {
+			return nil, fmt.Errorf("par-list needs a bucket, eg oos:bucket")
+		}
+		return f.listPars(ctx, bucketName, directory)
+	case operationParDelete:
+		bucketName, _ := f.split("")
+		if bucketName == "" {
+			return nil, fmt.Errorf("par-delete needs a bucket, eg oos:bucket")
+		}
+		return nil, f.deletePar(ctx, bucketName, opt["id"])
+	case operationSetMetadata:
+		return f.setMetadata(ctx, "", opt)
+	case operationList:
+		return f.listPage(ctx, opt["start"], opt["limit"])
+	case operationListBuckets:
+		return f.listBucketNames(ctx, opt["compartment"])
+	case operationObjectHead:
+		if len(args) == 0 {
+			return nil, fmt.Errorf("object-head needs an object, eg oos:bucket path/to/object")
+		}
+		return f.objectHead(ctx, args[0])
+	case operationBucketCreate:
+		bucketName, _ := f.split("")
+		if bucketName == "" || f.rootDirectory != "" {
+			return nil, fmt.Errorf("bucket-create needs a bucket, eg oos:bucket")
+		}
+		return f.bucketCreate(ctx, bucketName, opt)
+	case operationBucketDelete:
+		bucketName, _ := f.split("")
+		if bucketName == "" || f.rootDirectory != "" {
+			return nil, fmt.Errorf("bucket-delete needs a bucket, eg oos:bucket")
+		}
+		return nil, f.bucketDelete(ctx, bucketName)
 	default:
 		return nil, fs.ErrorCommandNotFound
 	}
 }
 
-func (f *Fs) rename(ctx context.Context, remote, newName string) (interface{}, error) {
+// listBucketNames returns the names of the buckets in compartment or
+// the configured compartment if it is empty
+func (f *Fs) listBucketNames(ctx context.Context, compartment string) ([]string, error) {
+	if compartment == "" {
+		compartment = f.opt.Compartment
+	}
+	err := checkCompartment(compartment)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := f.listCompartmentBuckets(ctx, compartment)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Remote())
+	}
+	return names, nil
+}
+
+func (f *Fs) rename(ctx context.Context, remote, newName string, overwrite bool) (interface{}, error) {
 	if remote == "" {
 		return nil, fmt.Errorf("path to object file cannot be empty")
 	}
@@ -138,22 +600,11 @@ func (f *Fs) rename(ctx context.Context, remote, newName string) (interface{}, e
 		}
 		return nil, fs.ErrorNotAFile
 	}
-	details := objectstorage.RenameObjectDetails{
-		SourceName: common.String(objectPath),
-		NewName:    common.String(newName),
-	}
-	request := objectstorage.RenameObjectRequest{
-		NamespaceName:       common.String(f.opt.Namespace),
-		BucketName:          common.String(bucketName),
-		RenameObjectDetails: details,
-		OpcClientRequestId:  nil,
-		RequestMetadata:     common.RequestMetadata{},
-	}
-	var response objectstorage.RenameObjectResponse
-	err = f.pacer.Call(func() (bool, error) {
-		response, err = f.srv.RenameObject(ctx, request)
-		return shouldRetry(ctx, response.HTTPResponse(), err)
-	})
+	err = f.renameObject(ctx, bucketName, objectPath, f.opt.Enc.FromStandardPath(newName), overwrite)
+	var serviceErr common.ServiceError
+	if errors.As(err, &serviceErr) && serviceErr.GetHTTPStatusCode() == http.StatusPreconditionFailed {
+		return nil, fmt.Errorf("can't rename %v to %v: it already exists, use -o overwrite to replace it", objectPath, newName)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -205,10 +656,12 @@ func (f *Fs) listMultipartUploads(ctx context.Context, bucketName, directory str
 
 	var response objectstorage.ListMultipartUploadsResponse
 	for {
-		err = f.pacer.Call(func() (bool, error) {
-			response, err = f.srv.ListMultipartUploads(ctx, req)
+		err = f.pacer.Call(logRetries("ListMultipartUploads", func() (bool, error) {
+			reqCtx, cancel := f.requestContext(ctx)
+			defer cancel()
+			response, err = f.srv.ListMultipartUploads(reqCtx, req)
 			return shouldRetry(ctx, response.HTTPResponse(), err)
-		})
+		}))
 		if err != nil {
 			// fs.Debugf(f, "failed to list multi part uploads %v", err)
 			return uploads, err
@@ -226,3 +679,201 @@ func (f *Fs) listMultipartUploads(ctx context.Context, bucketName, directory str
 	}
 	return uploads, nil
 }
+
+// tierUsage is the number and size of the objects in a storage tier
+// and archival state
+type tierUsage struct {
+	Tier          string `json:"tier"`
+	ArchivalState string `json:"archivalState,omitempty"`
+	Count         int64  `json:"count"`
+	Size          int64  `json:"size"`
+}
+
+// usageByTier lists the objects under directory in bucketName and
+// totals them by storage tier and archival state
+func (f *Fs) usageByTier(ctx context.Context, bucketName, directory string) ([]tierUsage, error) {
+	if !f.listFields["storageTier"] || !f.listFields["archivalState"] {
+		return nil, errors.New("usage-by-tier needs list_fields to include storageTier and archivalState")
+	}
+	type tierKey struct {
+		tier          objectstorage.StorageTierEnum
+		archivalState objectstorage.ArchivalStateEnum
+	}
+	totals := map[tierKey]*tierUsage{}
+	err := f.list(ctx, bucketName, directory, "", false, true, 0, func(remote string, object *objectstorage.ObjectSummary, isDirectory bool) error {
+		if isDirectory {
+			return nil
+		}
+		key := tierKey{tier: object.StorageTier, archivalState: object.ArchivalState}
+		if key.tier == "" {
+			key.tier = objectstorage.StorageTierStandard
+		}
+		usage, ok := totals[key]
+		if !ok {
+			usage = &tierUsage{Tier: string(key.tier), ArchivalState: string(key.archivalState)}
+			totals[key] = usage
+		}
+		usage.Count++
+		if object.Size != nil {
+			usage.Size += *object.Size
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	usages := make([]tierUsage, 0, len(totals))
+	for _, usage := range totals {
+		usages = append(usages, *usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Tier != usages[j].Tier {
+			return usages[i].Tier < usages[j].Tier
+		}
+		return usages[i].ArchivalState < usages[j].ArchivalState
+	})
+	return usages, nil
+}
+
+// listPage is the output of the list backend command
+type listPage struct {
+	Objects   []listPageObject `json:"objects"`
+	NextStart string           `json:"nextStart,omitempty"`
+}
+
+// listPageObject is an object in a listPage
+type listPageObject struct {
+	Name         string     `json:"name"`
+	Size         int64      `json:"size"`
+	TimeModified *time.Time `json:"timeModified,omitempty"`
+}
+
+// listPage lists up to limit objects under the root of f starting
+// from the object called start. Both start and the names listed are
+// relative to the root.
+func (f *Fs) listPage(ctx context.Context, start, limit string) (*listPage, error) {
+	bucketName, directory := f.split("")
+	if bucketName == "" {
+		return nil, fmt.Errorf("list needs a bucket, eg oos:bucket")
+	}
+	chunkSize := f.opt.ListChunk
+	if limit != "" {
+		var err error
+		chunkSize, err = strconv.Atoi(limit)
+		if err != nil || chunkSize < 1 || chunkSize > maxListChunk {
+			return nil, fmt.Errorf("limit must be a number from 1 to %d, got %q", maxListChunk, limit)
+		}
+	}
+	prefix := ""
+	if directory != "" {
+		prefix = directory + "/"
+	}
+	request := objectstorage.ListObjectsRequest{
+		NamespaceName: common.String(f.opt.Namespace),
+		BucketName:    common.String(bucketName),
+		Prefix:        common.String(prefix),
+		Limit:         common.Int(chunkSize),
+		Fields:        common.String(f.listFieldsParam()),
+	}
+	if start != "" {
+		request.Start = common.String(prefix + f.opt.Enc.FromStandardPath(start))
+	}
+	var resp objectstorage.ListObjectsResponse
+	err := f.pacer.Call(logRetries("ListObjects", func() (bool, error) {
+		reqCtx, cancel := f.requestContext(ctx)
+		defer cancel()
+		var err error
+		resp, err = f.srv.ListObjects(reqCtx, request)
+		return shouldRetry(ctx, resp.HTTPResponse(), err)
+	}))
+	if err != nil {
+		return nil, err
+	}
+	// relative returns the standard name of an object relative to the root
+	relative := func(name string) string {
+		return f.opt.Enc.ToStandardPath(strings.TrimPrefix(name, prefix))
+	}
+	page := &listPage{Objects: []listPageObject{}}
+	for _, object := range resp.Objects {
+		item := listPageObject{Name: relative(*object.Name)}
+		if object.Size != nil {
+			item.Size = *object.Size
+		}
+		if object.TimeModified != nil {
+			item.TimeModified = &object.TimeModified.Time
+		}
+		page.Objects = append(page.Objects, item)
+	}
+	if resp.NextStartWith != nil {
+		page.NextStart = relative(*resp.NextStartWith)
+	}
+	return page, nil
+}
+
+// objectHeadResult is the output of the object-head backend command
+type objectHeadResult struct {
+	Name               string            `json:"name"`
+	Size               int64             `json:"size"`
+	ETag               string            `json:"etag,omitempty"`
+	ContentMD5         string            `json:"contentMd5,omitempty"`
+	MultipartMD5       string            `json:"multipartMd5,omitempty"`
+	CRC32C             string            `json:"crc32c,omitempty"`
+	ContentType        string            `json:"contentType,omitempty"`
+	ContentEncoding    string            `json:"contentEncoding,omitempty"`
+	ContentLanguage    string            `json:"contentLanguage,omitempty"`
+	ContentDisposition string            `json:"contentDisposition,omitempty"`
+	CacheControl       string            `json:"cacheControl,omitempty"`
+	StorageTier        string            `json:"storageTier,omitempty"`
+	ArchivalState      string            `json:"archivalState,omitempty"`
+	TimeOfArchival     *time.Time        `json:"timeOfArchival,omitempty"`
+	VersionID          string            `json:"versionId,omitempty"`
+	LastModified       *time.Time        `json:"lastModified,omitempty"`
+	Metadata           map[string]string `json:"metadata"`
+}
+
+// objectHead reads the object remote with a single HEAD request and
+// returns everything object storage says about it
+func (f *Fs) objectHead(ctx context.Context, remote string) (*objectHeadResult, error) {
+	o := &Object{fs: f, remote: remote}
+	if bucketName, bucketPath := o.split(); bucketName == "" || bucketPath == "" {
+		return nil, errors.New("object-head needs an object, eg oos:bucket path/to/object")
+	}
+	info, err := o.headObject(ctx)
+	if err != nil {
+		return nil, err
+	}
+	str := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	result := &objectHeadResult{
+		Name:               remote,
+		ETag:               str(info.ETag),
+		ContentMD5:         str(info.ContentMd5),
+		MultipartMD5:       str(info.OpcMultipartMd5),
+		ContentType:        str(info.ContentType),
+		ContentEncoding:    str(info.ContentEncoding),
+		ContentLanguage:    str(info.ContentLanguage),
+		ContentDisposition: str(info.ContentDisposition),
+		CacheControl:       str(info.CacheControl),
+		StorageTier:        string(info.StorageTier),
+		ArchivalState:      string(info.ArchivalState),
+		VersionID:          str(info.VersionId),
+		Metadata:           metadataWithOpcPrefix(info.OpcMeta),
+	}
+	if info.ContentLength != nil {
+		result.Size = *info.ContentLength
+	}
+	if info.RawResponse != nil {
+		result.CRC32C = info.RawResponse.Header.Get(crc32cHeader)
+	}
+	if info.LastModified != nil {
+		result.LastModified = &info.LastModified.Time
+	}
+	if info.TimeOfArchival != nil {
+		result.TimeOfArchival = &info.TimeOfArchival.Time
+	}
+	return result, nil
+}
@@ -5,15 +5,21 @@ package oracleobjectstorage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/ncw/swift/v2"
 	"github.com/oracle/oci-go-sdk/v65/common"
 	"github.com/oracle/oci-go-sdk/v65/objectstorage"
 	"github.com/rclone/rclone/fs"
 )
 
+// number of times the HEAD of a newly copied object is retried if it
+// isn't found
+const copyNotFoundRetries = 5
+
 // ------------------------------------------------------------
 // Implement Copier is an optional interfaces for Fs
 //------------------------------------------------------------
@@ -24,83 +30,244 @@ import (
 // Will only be called if src.Fs().Name() == f.Name()
 // If it isn't possible then return fs.ErrorCantCopy
 func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	if f.opt.DisableServerSideCopy {
+		fs.Debugf(src, "Can't copy - disable_server_side_copy is set, will download and upload")
+		return nil, fs.ErrorCantCopy
+	}
 	// fs.Debugf(f, "copying %v to %v", src.Remote(), remote)
 	srcObj, ok := src.(*Object)
 	if !ok {
 		// fs.Debugf(src, "Can't copy - not same remote type")
 		return nil, fs.ErrorCantCopy
 	}
+	if srcObj.fs.name != f.name && !f.opt.ServerSideAcrossConfigs && !fs.GetConfig(ctx).ServerSideAcrossConfigs && !f.sameService(srcObj.fs) {
+		fs.Debugf(src, "Can't copy - not in the same namespace and region and server_side_across_configs isn't set")
+		return nil, fs.ErrorCantCopy
+	}
+	newInfo, err := srcObj.copyMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return f.copyObject(ctx, srcObj, remote, newInfo, f.opt.PreserveTier)
+}
+
+// copyObject does a server-side copy of srcObj to remote and returns
+// the new object.
+//
+// If newInfo is nil then the metadata will be copied otherwise it
+// will be replaced with newInfo. If preserveTier is set the copy keeps
+// the storage tier of srcObj.
+func (f *Fs) copyObject(ctx context.Context, srcObj *Object, remote string, newInfo map[string]string, preserveTier bool) (fs.Object, error) {
+	if preserveTier && srcObj.storageTier == nil {
+		// read the tier to copy it to the destination
+		err := srcObj.readMetaData(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	fs.Debugf(srcObj, "Copying with a single server-side copy work request")
 	// Temporary Object under construction
 	dstObj := &Object{
 		fs:     f,
 		remote: remote,
 	}
-	err := f.copy(ctx, dstObj, srcObj)
+	err := f.copy(ctx, dstObj, srcObj, newInfo, preserveTier)
+	switch {
+	case srcObj.fs.opt.Namespace != f.opt.Namespace && isNotAuthorized(err):
+		// copies between tenancies need policies which may not be
+		// in place, in which case download and upload instead
+		fs.Debugf(srcObj, "Can't copy - not authorized to copy from namespace %q to %q: %v", srcObj.fs.opt.Namespace, f.opt.Namespace, err)
+		return nil, fs.ErrorCantCopy
+	case isCopyRejected(err):
+		fs.Debugf(srcObj, "Can't copy - server-side copy rejected, will download and upload: %v", err)
+		return nil, fs.ErrorCantCopy
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The copy may not be visible straight after the work request
+	// completes so retry the HEAD if it isn't found
+	info, err := dstObj.headObjectRetryNotFound(ctx, copyNotFoundRetries)
+	if err != nil {
+		return nil, err
+	}
+	err = dstObj.decodeMetaDataHead(info)
+	if err != nil {
+		return nil, err
+	}
+	return dstObj, nil
+}
+
+// copyMetadata returns the metadata to replace the metadata of a copy
+// of o with, or nil if the metadata should be copied from o.
+//
+// The metadata is only replaced if --metadata is in use and some is
+// set with --metadata-set, in which case it is merged with the
+// metadata of o.
+func (o *Object) copyMetadata(ctx context.Context) (map[string]string, error) {
+	ci := fs.GetConfig(ctx)
+	if ci.MetadataSet == nil {
+		return nil, nil
+	}
+	set, err := fs.GetMetadataOptions(ctx, o, []fs.OpenOption{fs.MetadataOption(ci.MetadataSet)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata from source object: %w", err)
+	}
+	if len(set) == 0 {
+		return nil, nil
+	}
+	// read the metadata which isn't being replaced
+	err = o.readMetaData(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return f.NewObject(ctx, remote)
+	return o.replacementMetadata(set), nil
+}
+
+// replacementMetadata returns the metadata of o, with the keys in set
+// overriding it, in the form used to replace the metadata of a copy.
+//
+// The content headers are included as well as the user metadata as
+// replacing the metadata of a copy replaces them too.
+func (o *Object) replacementMetadata(set fs.Metadata) map[string]string {
+	meta := metadataWithOpcPrefix(o.meta)
+	if o.mimeType != "" {
+		meta["content-type"] = o.mimeType
+	}
+	if o.encoding != "" {
+		meta["content-encoding"] = o.encoding
+	}
+	for k, v := range set {
+		lowerKey := strings.ToLower(k)
+		switch lowerKey {
+		case "cache-control", "content-disposition", "content-encoding", "content-language", "content-type":
+			meta[lowerKey] = v
+		case "tier", "storage-tier":
+			if o.storageTier == nil || !strings.EqualFold(v, *o.storageTier) {
+				fs.Debugf(o, "Not changing the storage tier of a server-side copy")
+			}
+		case metaRetentionUntil:
+			// read only
+		case "mtime":
+			// rclone stores the mtime in its own format
+			if modTime, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				meta[ociMetaPrefix+metaMtime] = swift.TimeToFloatString(modTime)
+			} else {
+				fs.Debugf(o, "Failed to parse metadata %s: %q: %v", k, v, err)
+			}
+		default:
+			if !strings.HasPrefix(lowerKey, ociMetaPrefix) {
+				lowerKey = ociMetaPrefix + lowerKey
+			}
+			meta[lowerKey] = v
+		}
+	}
+	return meta
 }
 
 // copy does a server-side copy from dstObj <- srcObj
 //
 // If newInfo is nil then the metadata will be copied otherwise it
 // will be replaced with newInfo
-func (f *Fs) copy(ctx context.Context, dstObj *Object, srcObj *Object) (err error) {
-	srcBucket, srcPath := srcObj.split()
+func (f *Fs) copy(ctx context.Context, dstObj *Object, srcObj *Object, newInfo map[string]string, preserveTier bool) (err error) {
+	srcBucket, _ := srcObj.split()
 	dstBucket, dstPath := dstObj.split()
+	f.listCache.forget(dstBucket, dstPath)
 	if dstBucket != srcBucket {
-		exists, err := f.bucketExists(ctx, dstBucket)
+		err = f.makeBucket(ctx, dstBucket)
 		if err != nil {
 			return err
 		}
-		if !exists {
-			err = f.makeBucket(ctx, dstBucket)
-			if err != nil {
-				return err
-			}
-		}
 	}
-	copyObjectDetails := objectstorage.CopyObjectDetails{
-		SourceObjectName:          common.String(srcPath),
-		DestinationRegion:         common.String(dstObj.fs.opt.Region),
-		DestinationNamespace:      common.String(dstObj.fs.opt.Namespace),
-		DestinationBucket:         common.String(dstBucket),
-		DestinationObjectName:     common.String(dstPath),
-		DestinationObjectMetadata: metadataWithOpcPrefix(srcObj.meta),
+	// The copy is requested from the source region, which may differ
+	// from the destination one when copying across configs.
+	//
+	// Within a namespace and region it is requested with the
+	// credentials of the destination, which object storage checks may
+	// read the source and write the destination, so remotes with
+	// different auth providers can copy between each other.
+	srcFs := srcObj.fs
+	requester := srcFs
+	if f.sameService(srcFs) {
+		requester = f
+	} else {
+		fs.Debugf(dstObj, "server-side copy from region %q namespace %q", srcFs.opt.Region, srcFs.opt.Namespace)
 	}
+	// at most copy_concurrency copies are requested and waited for
+	// at once
+	f.copyTokens.Get()
+	defer f.copyTokens.Put()
 	req := objectstorage.CopyObjectRequest{
-		NamespaceName:     common.String(srcObj.fs.opt.Namespace),
+		NamespaceName:     common.String(srcFs.opt.Namespace),
 		BucketName:        common.String(srcBucket),
-		CopyObjectDetails: copyObjectDetails,
+		CopyObjectDetails: copyObjectDetails(dstObj, srcObj, newInfo, preserveTier),
 	}
+	// the source is decrypted with its key and the copy encrypted
+	// with the key of the destination
+	req.OpcSourceSseCustomerAlgorithm, req.OpcSourceSseCustomerKey, req.OpcSourceSseCustomerKeySha256 = srcFs.sseKey.headers()
+	req.OpcSseCustomerAlgorithm, req.OpcSseCustomerKey, req.OpcSseCustomerKeySha256 = f.sseKey.headers()
 	var resp objectstorage.CopyObjectResponse
-	err = f.pacer.Call(func() (bool, error) {
-		resp, err = f.srv.CopyObject(ctx, req)
+	err = requester.pacer.Call(logRetries("CopyObject", func() (bool, error) {
+		reqCtx, cancel := requester.requestContext(ctx)
+		defer cancel()
+		resp, err = requester.srv.CopyObject(reqCtx, req)
 		return shouldRetry(ctx, resp.HTTPResponse(), err)
-	})
+	}))
 	if err != nil {
 		return err
 	}
 	workRequestID := resp.OpcWorkRequestId
 	timeout := time.Duration(f.opt.CopyTimeout)
 	dstName := dstObj.String()
+	if workRequestID != nil {
+		fs.Infof(dstName, "server-side copy started with work request %s", *workRequestID)
+	}
 	// https://docs.oracle.com/en-us/iaas/Content/Object/Tasks/copyingobjects.htm
 	// To enable server side copy object, customers will have to
 	// grant policy to objectstorage service to manage object-family
 	// Allow service objectstorage-<region_identifier> to manage object-family in tenancy
 	// Another option to avoid the policy is to download and reupload the file.
 	// This download upload will work for maximum file size limit of 5GB
-	err = copyObjectWaitForWorkRequest(ctx, workRequestID, dstName, timeout, f.srv)
+	pollInterval := time.Duration(f.opt.CopyPollInterval)
+	// The work request lives in the region the copy was requested from
+	err = copyObjectWaitForWorkRequest(ctx, workRequestID, dstName, timeout, pollInterval, requester.srv)
 	if err != nil {
 		return err
 	}
 	return err
 }
 
+// copyObjectDetails returns the details of a copy of srcObj to dstObj
+// which may be in a different region and namespace, replacing the
+// metadata with newInfo unless it is nil and keeping the storage tier
+// of srcObj if preserveTier is set or it is copied to itself
+func copyObjectDetails(dstObj *Object, srcObj *Object, newInfo map[string]string, preserveTier bool) objectstorage.CopyObjectDetails {
+	_, srcPath := srcObj.split()
+	dstBucket, dstPath := dstObj.split()
+	// Object storage has no API to copy a range of an object into a
+	// multipart upload part, so objects of any size are copied with a
+	// single asynchronous work request. There are no chunks to copy in
+	// parallel here.
+	details := objectstorage.CopyObjectDetails{
+		SourceObjectName:          common.String(srcPath),
+		DestinationRegion:         common.String(dstObj.fs.opt.Region),
+		DestinationNamespace:      common.String(dstObj.fs.opt.Namespace),
+		DestinationBucket:         common.String(dstBucket),
+		DestinationObjectName:     common.String(dstPath),
+		DestinationObjectMetadata: newInfo,
+	}
+	// copies of an object to itself to change its metadata always
+	// keep its tier
+	if (preserveTier || dstObj == srcObj) && srcObj.storageTier != nil {
+		details.DestinationObjectStorageTier, _ = objectstorage.GetMappingStorageTierEnum(*srcObj.storageTier)
+	}
+	return details
+}
+
 func copyObjectWaitForWorkRequest(ctx context.Context, wID *string, entityType string, timeout time.Duration,
-	client *objectstorage.ObjectStorageClient) error {
+	pollInterval time.Duration, client *objectstorage.ObjectStorageClient) error {
 
+	var lastPercent float32 = -1
 	stateConf := &StateChangeConf{
 		Pending: []string{
 			string(objectstorage.WorkRequestStatusAccepted),
@@ -117,20 +284,33 @@ func copyObjectWaitForWorkRequest(ctx context.Context, wID *string, entityType s
 			getWorkRequestRequest.WorkRequestId = wID
 			workRequestResponse, err := client.GetWorkRequest(context.Background(), getWorkRequestRequest)
 			wr := &workRequestResponse.WorkRequest
+			if err == nil && wr.PercentComplete != nil && *wr.PercentComplete != lastPercent {
+				lastPercent = *wr.PercentComplete
+				fs.Infof(entityType, "server-side copy %.0f%% complete, work request %s", lastPercent, *wID)
+			}
 			return workRequestResponse, string(wr.Status), err
 		},
-		Timeout: timeout,
+		Timeout:    timeout,
+		MaxTimeout: pollInterval,
 	}
 
 	wrr, e := stateConf.WaitForStateContext(ctx, entityType)
 	if e != nil {
+		var timeoutErr *TimeoutError
+		if errors.As(e, &timeoutErr) {
+			return fmt.Errorf("timed out after %v waiting for work request to copy, workId: %s, entity: %s. Message: %s",
+				timeout, *wID, entityType, e)
+		}
 		return fmt.Errorf("work request did not succeed, workId: %s, entity: %s. Message: %s", *wID, entityType, e)
 	}
 
 	wr := wrr.(objectstorage.GetWorkRequestResponse).WorkRequest
-	if wr.Status == objectstorage.WorkRequestStatusFailed {
+	switch wr.Status {
+	case objectstorage.WorkRequestStatusFailed:
 		errorMessage, _ := getObjectStorageErrorFromWorkRequest(ctx, wID, client)
-		return fmt.Errorf("work request did not succeed, workId: %s, entity: %s. Message: %s", *wID, entityType, errorMessage)
+		return fmt.Errorf("work request failed, workId: %s, entity: %s. Message: %s", *wID, entityType, errorMessage)
+	case objectstorage.WorkRequestStatusCanceled:
+		return fmt.Errorf("work request was canceled, workId: %s, entity: %s", *wID, entityType)
 	}
 
 	return nil
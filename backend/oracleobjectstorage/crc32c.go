@@ -0,0 +1,59 @@
+//go:build !plan9 && !solaris && !js
+// +build !plan9,!solaris,!js
+
+package oracleobjectstorage
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	gohash "hash"
+	"hash/crc32"
+	"net/http"
+
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// header object storage uses for the base64 big endian CRC32C of an
+// object or part
+const crc32cHeader = "opc-content-crc32c"
+
+var (
+	crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+	// CRC32CHashType is the hash.Type for the CRC32C object storage
+	// verifies uploads with
+	CRC32CHashType = hash.RegisterHash("crc32c", "CRC-32C", 8, func() gohash.Hash { return crc32.New(crc32cTable) })
+)
+
+// crc32cToBase64 converts a hex CRC32C as returned by Hash into the
+// base64 form used in the header, returning "" if it isn't valid
+func crc32cToBase64(crc string) string {
+	sum, err := hex.DecodeString(crc)
+	if err != nil || len(sum) != crc32.Size {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(sum)
+}
+
+// crc32cFromResponse returns the hex CRC32C of an object from its HEAD
+// or GET response, or "" if there isn't one.
+//
+// Multipart objects are skipped as their CRC32C isn't the CRC32C of
+// their data.
+func crc32cFromResponse(resp *http.Response) string {
+	if resp == nil || resp.Header.Get("opc-multipart-md5") != "" {
+		return ""
+	}
+	sum, err := base64.StdEncoding.DecodeString(resp.Header.Get(crc32cHeader))
+	if err != nil || len(sum) != crc32.Size {
+		return ""
+	}
+	return hex.EncodeToString(sum)
+}
+
+// crc32cBase64 returns the base64 CRC32C of data for the header
+func crc32cBase64(data []byte) string {
+	h := crc32.New(crc32cTable)
+	_, _ = h.Write(data)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
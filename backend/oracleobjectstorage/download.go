@@ -0,0 +1,138 @@
+//go:build !plan9 && !solaris && !js
+// +build !plan9,!solaris,!js
+
+package oracleobjectstorage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+// ------------------------------------------------------------
+// Concurrent ranged downloads
+// ------------------------------------------------------------
+
+// canDownloadConcurrently returns true if the object can be read with
+// concurrent ranged requests. The metadata must have been read.
+//
+// Ranges of an object with a Content-Encoding are ranges of the
+// encoded bytes so those are always read in one request.
+func (o *Object) canDownloadConcurrently() bool {
+	if o.encoding != "" && !strings.EqualFold(o.encoding, "identity") {
+		return false
+	}
+	return o.bytes > int64(o.fs.opt.ChunkSize)
+}
+
+// chunkResult is the outcome of downloading a single chunk
+type chunkResult struct {
+	data []byte
+	err  error
+}
+
+// concurrentReader reads an object with concurrent ranged GETs of
+// chunk_size returning the data in order.
+type concurrentReader struct {
+	cancel  context.CancelFunc
+	results chan chan chunkResult // pending chunks in order
+	current *bytes.Reader         // chunk being read
+	err     error                 // sticky error
+}
+
+// newConcurrentReader starts downloading o with download_concurrency
+// ranged requests in flight
+func (o *Object) newConcurrentReader(ctx context.Context) *concurrentReader {
+	ctx, cancel := context.WithCancel(ctx)
+	concurrency := o.fs.opt.DownloadConcurrency
+	chunkSize := int64(o.fs.opt.ChunkSize)
+	size := o.bytes
+	r := &concurrentReader{
+		cancel: cancel,
+		// the chunks in this channel and the one being read are the
+		// ones downloading or buffered
+		results: make(chan chan chunkResult, concurrency-1),
+	}
+	go func() {
+		defer close(r.results)
+		for offset := int64(0); offset < size; offset += chunkSize {
+			length := chunkSize
+			if offset+length > size {
+				length = size - offset
+			}
+			result := make(chan chunkResult, 1)
+			select {
+			case r.results <- result:
+			case <-ctx.Done():
+				return
+			}
+			go func(offset, length int64) {
+				data, err := o.downloadRange(ctx, offset, length)
+				result <- chunkResult{data: data, err: err}
+			}(offset, length)
+		}
+	}()
+	return r
+}
+
+// downloadRange reads length bytes of the object from offset
+func (o *Object) downloadRange(ctx context.Context, offset, length int64) ([]byte, error) {
+	bucketName, bucketPath := o.split()
+	req := objectstorage.GetObjectRequest{
+		NamespaceName: common.String(o.fs.opt.Namespace),
+		BucketName:    common.String(bucketName),
+		ObjectName:    common.String(bucketPath),
+		Range:         common.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+	}
+	req.OpcSseCustomerAlgorithm, req.OpcSseCustomerKey, req.OpcSseCustomerKeySha256 = o.fs.sseKey.headers()
+	data := make([]byte, length)
+	err := o.fs.pacer.Call(logRetries("GetObject", func() (bool, error) {
+		resp, err := o.fs.srv.GetObject(ctx, req)
+		if err != nil {
+			return shouldRetry(ctx, resp.HTTPResponse(), err)
+		}
+		_, err = io.ReadFull(resp.Content, data)
+		_ = resp.Content.Close()
+		return shouldRetry(ctx, resp.HTTPResponse(), err)
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download bytes %d-%d: %w", offset, offset+length-1, err)
+	}
+	return data, nil
+}
+
+// Read reads the data in order from the downloaded chunks
+func (r *concurrentReader) Read(p []byte) (n int, err error) {
+	for r.err == nil {
+		if r.current != nil && r.current.Len() > 0 {
+			return r.current.Read(p)
+		}
+		result, ok := <-r.results
+		if !ok {
+			r.err = io.EOF
+			break
+		}
+		chunk := <-result
+		if chunk.err != nil {
+			r.err = chunk.err
+			break
+		}
+		r.current = bytes.NewReader(chunk.data)
+	}
+	return 0, r.err
+}
+
+// Close stops any downloads in progress
+func (r *concurrentReader) Close() error {
+	r.cancel()
+	if r.err == nil {
+		r.err = os.ErrClosed
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+//go:build !plan9 && !solaris && !js
+// +build !plan9,!solaris,!js
+
+package oracleobjectstorage
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/rclone/rclone/lib/pacer"
+)
+
+// jitterCalculator is a pacer.Calculator which backs off exponentially
+// like pacer.Default but sleeps for a random time between half and all
+// of the backoff when retrying.
+//
+// With lots of --transfers many calls are throttled at once, so without
+// the jitter they would all retry at the same moment and be throttled
+// again.
+type jitterCalculator struct {
+	backoff *pacer.Default
+	sleep   time.Duration       // the sleep time before the jitter
+	randN   func(n int64) int64 // returns a random number in [0,n)
+}
+
+// newJitterCalculator makes a jitterCalculator backing off with the
+// options given
+func newJitterCalculator(opts ...pacer.DefaultOption) *jitterCalculator {
+	return &jitterCalculator{
+		backoff: pacer.NewDefault(opts...),
+		randN:   rand.Int63n,
+	}
+}
+
+// Calculate takes the current Pacer state and return the wait time until the next try.
+//
+// The backoff is worked out from the sleep time before the jitter so
+// the jitter doesn't slow down how fast it grows. A time asked for with
+// Retry-After is used as it is.
+func (c *jitterCalculator) Calculate(state pacer.State) time.Duration {
+	state.SleepTime = c.sleep
+	c.sleep = c.backoff.Calculate(state)
+	if _, ok := pacer.IsRetryAfter(state.LastError); ok || state.ConsecutiveRetries == 0 {
+		return c.sleep
+	}
+	half := c.sleep / 2
+	if half <= 0 {
+		return c.sleep
+	}
+	return c.sleep - half + time.Duration(c.randN(int64(half)+1))
+}
@@ -0,0 +1,101 @@
+//go:build !plan9 && !solaris && !js
+// +build !plan9,!solaris,!js
+
+package oracleobjectstorage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+// listCache remembers the objects seen in recursive listings for a
+// while so NewObject can find them without a HEAD request.
+//
+// A nil *listCache is valid and caches nothing.
+type listCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]listCacheEntry // by bucket and object name
+	swept   time.Time                 // when expired entries were last dropped
+}
+
+// listCacheEntry is an object seen in a listing
+type listCacheEntry struct {
+	info    *objectstorage.ObjectSummary
+	expires time.Time
+}
+
+// newListCache makes a listCache which remembers objects for ttl, or
+// returns nil if ttl is 0
+func newListCache(ttl time.Duration) *listCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &listCache{
+		ttl:     ttl,
+		entries: map[string]listCacheEntry{},
+		swept:   time.Now(),
+	}
+}
+
+// listCacheKey returns the key for the object name in bucketName
+func listCacheKey(bucketName, name string) string {
+	return bucketName + "/" + name
+}
+
+// put remembers info, the listing of the object name in bucketName
+func (c *listCache) put(bucketName, name string, info *objectstorage.ObjectSummary) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if now.Sub(c.swept) > c.ttl {
+		// drop the expired entries once per ttl so the cache
+		// doesn't keep growing while nothing is being looked up
+		for key, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+		c.swept = now
+	}
+	c.entries[listCacheKey(bucketName, name)] = listCacheEntry{
+		info:    info,
+		expires: now.Add(c.ttl),
+	}
+}
+
+// get returns the listing of the object name in bucketName or nil if
+// it isn't cached or has expired
+func (c *listCache) get(bucketName, name string) *objectstorage.ObjectSummary {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := listCacheKey(bucketName, name)
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil
+	}
+	return entry.info
+}
+
+// forget drops the object name in bucketName from the cache as it has
+// been changed or removed
+func (c *listCache) forget(bucketName, name string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, listCacheKey(bucketName, name))
+}
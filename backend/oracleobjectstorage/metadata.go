@@ -0,0 +1,120 @@
+//go:build !plan9 && !solaris && !js
+// +build !plan9,!solaris,!js
+
+package oracleobjectstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/lib/pacer"
+)
+
+// setMetadataResult is the output of the set-metadata backend command
+type setMetadataResult struct {
+	Updated int      `json:"updated"`
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// setMetadata sets the metadata in opt on the objects under dir
+// without uploading them again.
+//
+// Object storage can't change the metadata of an object so each one
+// is copied server-side to itself replacing it, --transfers at once.
+// Archived objects can't be copied so are skipped.
+func (f *Fs) setMetadata(ctx context.Context, dir string, opt map[string]string) (*setMetadataResult, error) {
+	bucketName, directory := f.split(dir)
+	if bucketName == "" {
+		return nil, errors.New("set-metadata needs a bucket, eg oos:bucket/path")
+	}
+	if len(opt) == 0 {
+		return nil, errors.New("set-metadata needs some metadata to set, eg -o content-type=text/html")
+	}
+	set := fs.Metadata(opt)
+	var (
+		wg      sync.WaitGroup
+		tokens  = pacer.NewTokenDispenser(f.ci.Transfers)
+		mu      sync.Mutex // protects result, failed and lastErr
+		result  = &setMetadataResult{}
+		failed  int
+		lastErr error
+	)
+	err := f.list(ctx, bucketName, directory, f.rootPrefix(), f.rootBucket == "", true, 0, func(remote string, object *objectstorage.ObjectSummary, isDirectory bool) error {
+		if isDirectory {
+			return nil
+		}
+		tokens.Get()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer tokens.Put()
+			skipped, err := f.setObjectMetadata(ctx, remote, set)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				fs.Errorf(remote, "Failed to set metadata: %v", err)
+				failed++
+				lastErr = err
+			case skipped:
+				result.Skipped = append(result.Skipped, remote)
+			default:
+				result.Updated++
+			}
+		}()
+		return nil
+	})
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(result.Skipped)
+	if failed > 0 {
+		return result, fmt.Errorf("failed to set metadata on %d objects: %w", failed, lastErr)
+	}
+	return result, nil
+}
+
+// setObjectMetadata sets the metadata in set on the object at remote
+// by copying it to itself, returning true if it was skipped
+func (f *Fs) setObjectMetadata(ctx context.Context, remote string, set fs.Metadata) (skipped bool, err error) {
+	o := &Object{
+		fs:     f,
+		remote: remote,
+	}
+	// read the metadata which isn't being replaced and the tier
+	info, err := o.headObject(ctx)
+	if err != nil {
+		return false, err
+	}
+	err = o.decodeMetaDataHead(info)
+	if err != nil {
+		return false, err
+	}
+	if o.storageTier != nil && *o.storageTier == archive {
+		fs.Logf(o, "Not setting metadata on object in the archive storage tier")
+		return true, nil
+	}
+	if operations.SkipDestructive(ctx, o, "set metadata") {
+		return true, nil
+	}
+	// keep the content headers which replacementMetadata doesn't
+	keep := fs.Metadata{}
+	for key, value := range map[string]*string{
+		"cache-control":       info.CacheControl,
+		"content-disposition": info.ContentDisposition,
+		"content-language":    info.ContentLanguage,
+	} {
+		if value != nil && *value != "" {
+			keep[key] = *value
+		}
+	}
+	keep.Merge(set)
+	return false, f.copy(ctx, o, o, o.replacementMetadata(keep), f.opt.PreserveTier)
+}
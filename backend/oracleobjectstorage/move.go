@@ -0,0 +1,225 @@
+//go:build !plan9 && !solaris && !js
+// +build !plan9,!solaris,!js
+
+package oracleobjectstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// errFoundObject stops a listing once an object has been found
+var errFoundObject = errors.New("found object")
+
+// DirMove moves src, srcRemote to this remote at dstRemote
+// using server-side move operations.
+//
+// Object storage has no directories so this renames each object under
+// srcRemote, copy_concurrency at once. This is only possible within a
+// bucket.
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantDirMove
+//
+// If destination exists then return fs.ErrorDirExists
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string) error {
+	srcFs, ok := src.(*Fs)
+	if !ok {
+		fs.Debugf(src, "Can't move directory - not same remote type")
+		return fs.ErrorCantDirMove
+	}
+	if !f.sameService(srcFs) {
+		fs.Debugf(srcFs, "Can't move directory - not in the same namespace and region")
+		return fs.ErrorCantDirMove
+	}
+	srcBucket, srcPath := srcFs.split(srcRemote)
+	dstBucket, dstPath := f.split(dstRemote)
+	if srcBucket != dstBucket || srcPath == "" || dstPath == "" {
+		fs.Debugf(srcFs, "Can't move directory - renames only work within a bucket")
+		return fs.ErrorCantDirMove
+	}
+
+	// the destination mustn't have any objects in it
+	err := f.list(ctx, dstBucket, dstPath, "", false, true, 1, func(remote string, object *objectstorage.ObjectSummary, isDirectory bool) error {
+		return errFoundObject
+	})
+	if err == errFoundObject {
+		return fs.ErrorDirExists
+	} else if err != nil && err != fs.ErrorDirNotFound {
+		return err
+	}
+
+	var (
+		wg      sync.WaitGroup
+		tokens  = f.copyTokens
+		mu      sync.Mutex // protects failed and lastErr
+		failed  []string
+		lastErr error
+		renamed int
+		// the names are listed decoded with the encoding of the
+		// source and encoded again with that of the destination
+		srcNames = srcFs.opt.Enc.ToStandardPath(srcPath) + "/"
+	)
+	err = srcFs.list(ctx, srcBucket, srcPath, "", false, true, 0, func(remote string, object *objectstorage.ObjectSummary, isDirectory bool) error {
+		if isDirectory || !strings.HasPrefix(remote, srcNames) {
+			return nil
+		}
+		srcName := srcFs.opt.Enc.FromStandardPath(remote)
+		dstName := dstPath + "/" + f.opt.Enc.FromStandardPath(remote[len(srcNames):])
+		renamed++
+		tokens.Get()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer tokens.Put()
+			err := f.renameObject(ctx, srcBucket, srcName, dstName, true)
+			if err != nil {
+				fs.Errorf(srcFs, "Failed to move %q to %q: %v", srcName, dstName, err)
+				mu.Lock()
+				failed = append(failed, srcName)
+				lastErr = err
+				mu.Unlock()
+			}
+		}()
+		return nil
+	})
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	if renamed == 0 {
+		return fs.ErrorDirNotFound
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to move %d of %d objects, including %q: %w", len(failed), renamed, failed[0], lastErr)
+	}
+	return nil
+}
+
+// Move src to this remote using server-side move operations.
+//
+// Within a bucket the object is renamed, which keeps everything about
+// it. Otherwise it is copied server-side with its metadata, content
+// headers, mtime and storage tier, and src is only deleted once the
+// copy has been checked.
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantMove
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	if f.opt.DisableServerSideCopy {
+		fs.Debugf(src, "Can't move - disable_server_side_copy is set, will download and upload")
+		return nil, fs.ErrorCantMove
+	}
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debugf(src, "Can't move - not same remote type")
+		return nil, fs.ErrorCantMove
+	}
+	srcFs := srcObj.fs
+	if srcFs.name != f.name && !f.opt.ServerSideAcrossConfigs && !fs.GetConfig(ctx).ServerSideAcrossConfigs && !f.sameService(srcFs) {
+		fs.Debugf(src, "Can't move - not in the same namespace and region and server_side_across_configs isn't set")
+		return nil, fs.ErrorCantMove
+	}
+	srcBucket, srcPath := srcObj.split()
+	dstBucket, dstPath := f.split(remote)
+	if srcBucket == dstBucket && f.sameService(srcFs) && sameSSECustomerKey(f.sseKey, srcFs.sseKey) {
+		err := f.renameObject(ctx, dstBucket, srcPath, dstPath, true)
+		if err != nil {
+			return nil, err
+		}
+		dstObj := &Object{
+			fs:     f,
+			remote: remote,
+		}
+		err = dstObj.readMetaData(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return dstObj, nil
+	}
+	newInfo, err := srcObj.copyMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := f.copyObject(ctx, srcObj, remote, newInfo, true)
+	if errors.Is(err, fs.ErrorCantCopy) {
+		return nil, fs.ErrorCantMove
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = checkMoved(ctx, srcObj, dst.(*Object))
+	if err != nil {
+		return nil, err
+	}
+	err = srcObj.Remove(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("copied but failed to delete the source: %w", err)
+	}
+	return dst, nil
+}
+
+// checkMoved checks the copy dstObj of srcObj has the same size and
+// MD5 before srcObj is deleted
+func checkMoved(ctx context.Context, srcObj, dstObj *Object) error {
+	if srcObj.Size() != dstObj.Size() {
+		return fmt.Errorf("move of %q failed: copy is %d bytes but the source is %d bytes, not deleting the source", srcObj.remote, dstObj.Size(), srcObj.Size())
+	}
+	srcMD5, _ := srcObj.Hash(ctx, hash.MD5)
+	dstMD5, _ := dstObj.Hash(ctx, hash.MD5)
+	if srcMD5 != "" && dstMD5 != "" && srcMD5 != dstMD5 {
+		return fmt.Errorf("move of %q failed: copy has MD5 %s but the source has %s, not deleting the source", srcObj.remote, dstMD5, srcMD5)
+	}
+	return nil
+}
+
+// sameSSECustomerKey returns true if objects encrypted with a can be
+// read with b
+func sameSSECustomerKey(a, b *sseCustomerKey) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// sameService returns true if f and other are in the same namespace
+// and region, so requests for either can be made with the credentials
+// of the other
+func (f *Fs) sameService(other *Fs) bool {
+	return f.opt.Namespace == other.opt.Namespace && f.srv.Host == other.srv.Host
+}
+
+// renameObject renames srcName to dstName in bucketName, replacing
+// dstName if it exists if overwrite is set or failing with a 412
+// Precondition Failed error otherwise
+func (f *Fs) renameObject(ctx context.Context, bucketName, srcName, dstName string, overwrite bool) error {
+	f.listCache.forget(bucketName, srcName)
+	f.listCache.forget(bucketName, dstName)
+	request := objectstorage.RenameObjectRequest{
+		NamespaceName: common.String(f.opt.Namespace),
+		BucketName:    common.String(bucketName),
+		RenameObjectDetails: objectstorage.RenameObjectDetails{
+			SourceName: common.String(srcName),
+			NewName:    common.String(dstName),
+		},
+	}
+	if !overwrite {
+		request.NewObjIfNoneMatchETag = common.String("*")
+	}
+	return f.pacer.Call(logRetries("RenameObject", func() (bool, error) {
+		reqCtx, cancel := f.requestContext(ctx)
+		defer cancel()
+		response, err := f.srv.RenameObject(reqCtx, request)
+		return shouldRetry(ctx, response.HTTPResponse(), err)
+	}))
+}
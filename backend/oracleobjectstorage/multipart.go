@@ -0,0 +1,474 @@
+//go:build !plan9 && !solaris && !js
+// +build !plan9,!solaris,!js
+
+package oracleobjectstorage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/chunksize"
+	"github.com/rclone/rclone/lib/atexit"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/pool"
+	"github.com/rclone/rclone/lib/readers"
+	"github.com/shirou/gopsutil/v3/mem"
+	"golang.org/x/sync/errgroup"
+)
+
+// number of parts of a stream uploaded before the part size doubles
+const streamPartsPerDoubling = 1000
+
+// streamPartSize returns the size of part partNum of an upload of
+// unknown size.
+//
+// The first streamPartsPerDoubling parts are chunkSize and the size
+// doubles for each streamPartsPerDoubling parts after that, so streams
+// much larger than maxUploadParts * chunkSize can still be uploaded
+// without making small uploads use more memory.
+func streamPartSize(chunkSize fs.SizeSuffix, partNum int) fs.SizeSuffix {
+	partSize := chunkSize
+	for i := streamPartsPerDoubling; i < partNum && partSize < maxChunkSize; i += streamPartsPerDoubling {
+		partSize *= 2
+	}
+	if partSize > maxChunkSize {
+		partSize = maxChunkSize
+	}
+	return partSize
+}
+
+// streamMaxSize returns the largest stream which can be uploaded in
+// parts parts as sized by streamPartSize
+func streamMaxSize(chunkSize fs.SizeSuffix, parts int) fs.SizeSuffix {
+	var total fs.SizeSuffix
+	for partNum := 1; partNum <= parts; partNum++ {
+		total += streamPartSize(chunkSize, partNum)
+	}
+	return total
+}
+
+// bufferStream reads the start of a stream of unknown size.
+//
+// If the whole stream fits in one chunk it returns a reader of it and
+// its size, so that it can be uploaded with a single PutObject,
+// otherwise it returns a reader of the whole stream and a size of -1.
+func bufferStream(in io.Reader, chunkSize fs.SizeSuffix) (io.Reader, int64, error) {
+	buf := make([]byte, chunkSize)
+	n, err := readers.ReadFill(in, buf)
+	if err == io.EOF {
+		return bytes.NewReader(buf[:n]), int64(n), nil
+	}
+	if err != nil {
+		return nil, -1, err
+	}
+	return io.MultiReader(bytes.NewReader(buf), in), -1, nil
+}
+
+// newMemoryPool makes a pool of buffers of size bytes for the parts
+// of multipart uploads
+func (f *Fs) newMemoryPool(size int64) *pool.Pool {
+	return pool.New(
+		time.Duration(f.opt.MemoryPoolFlushTime),
+		int(size),
+		f.opt.UploadConcurrency.max()*f.ci.Transfers,
+		f.opt.MemoryPoolUseMmap,
+	)
+}
+
+// totalMemory returns the memory of the machine in bytes or 0 if it
+// can't be read
+var totalMemory = func() int64 {
+	v, err := mem.VirtualMemory()
+	if err != nil {
+		return 0
+	}
+	return int64(v.Total)
+}
+
+// checkUploadMemory logs the most memory the buffers of the parts of
+// multipart uploads may use and warns if that is more than a quarter
+// of the memory of the machine, as it may then run out.
+//
+// Large files of known size may use bigger parts so this is only a
+// guide.
+func (f *Fs) checkUploadMemory() {
+	perTransfer := fs.SizeSuffix(int64(f.opt.ChunkSize) * int64(f.opt.UploadConcurrency.max()))
+	total := perTransfer * fs.SizeSuffix(f.ci.Transfers)
+	fs.Debugf(f, "Multipart uploads may buffer %v per transfer, %v for %d transfers", perTransfer, total, f.ci.Transfers)
+	memory := fs.SizeSuffix(totalMemory())
+	if memory > 0 && total > memory/4 {
+		fs.Logf(f, "Multipart uploads may buffer %v for %d transfers (chunk_size %v * upload_concurrency %v * --transfers) which is more than a quarter of the %v of memory: reduce them if rclone runs out of memory",
+			total, f.ci.Transfers, f.opt.ChunkSize, f.opt.UploadConcurrency, memory)
+	}
+}
+
+// getMemoryPool returns the shared pool if size is the chunk size,
+// otherwise a new pool for parts of size bytes
+func (f *Fs) getMemoryPool(size int64) *pool.Pool {
+	if size == int64(f.opt.ChunkSize) {
+		return f.pool
+	}
+	return f.newMemoryPool(size)
+}
+
+// uploadPartSize returns the part size and the number of parts to
+// upload at once for a multipart upload of size bytes in at most
+// uploadParts parts.
+//
+// The concurrency is upload_concurrency, or scaled with size if that
+// is auto.
+//
+// The chunk size is increased if necessary to fit the upload in
+// uploadParts parts, in which case the concurrency is reduced if
+// needed to keep the memory used within concurrency * chunk_size.
+func (f *Fs) uploadPartSize(o fs.Object, size int64, uploadParts int) (partSize fs.SizeSuffix, concurrency int) {
+	concurrency = f.opt.UploadConcurrency.forSize(size)
+	if size < 0 {
+		return f.opt.ChunkSize, concurrency
+	}
+	partSize = chunksize.Calculator(o, size, uploadParts, f.opt.ChunkSize)
+	memoryBudget := f.opt.ChunkSize * fs.SizeSuffix(concurrency)
+	if maxConcurrency := int(memoryBudget / partSize); maxConcurrency < concurrency {
+		if maxConcurrency < 1 {
+			maxConcurrency = 1
+		}
+		fs.Logf(o, "Reducing upload concurrency from %d to %d to keep %v chunks within %v of memory",
+			concurrency, maxConcurrency, partSize, memoryBudget)
+		concurrency = maxConcurrency
+	}
+	fs.Debugf(o, "Multipart upload of %v using chunk size %v and concurrency %d", fs.SizeSuffix(size), partSize, concurrency)
+	return partSize, concurrency
+}
+
+// uploadMultipart uploads in to the object described by req in parts,
+// uploading upload_concurrency parts at once.
+//
+// size is -1 if it isn't known, in which case the part size grows as
+// described by streamPartSize.
+//
+// If --oos-no-head is set the metadata of o is set from the commit
+// rather than being read with a HEAD afterwards.
+func (o *Object) uploadMultipart(ctx context.Context, req *objectstorage.CreateMultipartUploadRequest, size int64, in io.Reader) (err error) {
+	f := o.fs
+	bucketName, bucketPath := *req.BucketName, *req.Object
+
+	uploadParts := f.opt.MaxUploadParts
+	if uploadParts < 1 {
+		uploadParts = 1
+	} else if uploadParts > maxUploadParts {
+		uploadParts = maxUploadParts
+	}
+	partSize, concurrency := f.uploadPartSize(o, size, uploadParts)
+	tokens := pacer.NewTokenDispenser(concurrency)
+
+	var resp objectstorage.CreateMultipartUploadResponse
+	err = f.pacer.Call(logRetries("CreateMultipartUpload", func() (bool, error) {
+		reqCtx, cancel := f.requestContext(ctx)
+		defer cancel()
+		resp, err = f.srv.CreateMultipartUpload(reqCtx, *req)
+		return shouldRetry(ctx, resp.HTTPResponse(), err)
+	}))
+	if err != nil {
+		return fmt.Errorf("multipart upload failed to initialise: %w", err)
+	}
+	uploadID := resp.UploadId
+
+	f.addUpload(o, bucketName, bucketPath, *uploadID)
+	defer f.removeUpload(*uploadID)
+	defer atexit.OnError(&err, func() {
+		_ = f.abortUpload(context.Background(), *uploadID)
+	})()
+
+	var (
+		g, gCtx  = errgroup.WithContext(ctx)
+		finished = false
+		partsMu  sync.Mutex // to protect parts
+		parts    []objectstorage.CommitMultipartUploadPartDetails
+		off      int64
+		md5sMu   sync.Mutex
+		md5s     []byte
+		hasher   = md5.New() // MD5 of the whole object
+	)
+
+	addMd5 := func(md5binary *[md5.Size]byte, partNum int) {
+		md5sMu.Lock()
+		defer md5sMu.Unlock()
+		start := partNum * md5.Size
+		end := start + md5.Size
+		if extend := end - len(md5s); extend > 0 {
+			md5s = append(md5s, make([]byte, extend)...)
+		}
+		copy(md5s[start:end], (*md5binary)[:])
+	}
+
+	// readErr stops reading the source, and is returned once the
+	// parts already started have finished uploading
+	var readErr error
+	memPool := f.getMemoryPool(int64(partSize))
+	for partNum := 1; !finished; partNum++ {
+		if partNum > uploadParts {
+			// all the parts are full, which is only too many if
+			// there is more to read
+			var peek [1]byte
+			n, peekErr := io.ReadFull(in, peek[:])
+			switch {
+			case n == 0 && peekErr == io.EOF:
+			case n == 0:
+				readErr = fmt.Errorf("multipart upload failed to read source: %w", peekErr)
+			default:
+				readErr = fmt.Errorf("multipart upload failed: more than %d parts needed", uploadParts)
+			}
+			break
+		}
+		if size < 0 {
+			if newPartSize := streamPartSize(f.opt.ChunkSize, partNum); newPartSize != partSize {
+				partSize = newPartSize
+				memPool = f.getMemoryPool(int64(partSize))
+			}
+		}
+
+		// Get a block of memory from the pool and token which limits concurrency.
+		tokens.Get()
+		memPool := memPool
+		buf := memPool.Get()
+
+		free := func() {
+			// return the memory and token
+			memPool.Put(buf)
+			tokens.Put()
+		}
+
+		// Fail fast, in case an errgroup managed function returns an error
+		// gCtx is cancelled. There is no point in uploading all the other parts.
+		if gCtx.Err() != nil {
+			free()
+			break
+		}
+
+		// Read the chunk
+		var n int
+		n, err = readers.ReadFill(in, buf) // this can never return 0, nil
+		if err == io.EOF {
+			if n == 0 && partNum != 1 { // end if no data and if not first chunk
+				free()
+				break
+			}
+			finished = true
+		} else if err != nil {
+			free()
+			readErr = fmt.Errorf("multipart upload failed to read source: %w", err)
+			break
+		}
+		buf = buf[:n]
+		if !f.opt.DisableChecksum {
+			_, _ = hasher.Write(buf)
+		}
+
+		partNum := partNum
+		fs.Debugf(o, "multipart upload starting chunk %d size %v offset %v/%v", partNum, fs.SizeSuffix(n), fs.SizeSuffix(off), fs.SizeSuffix(size))
+		off += int64(n)
+		if size < 0 && partNum == uploadParts*4/5 {
+			// warn while there is still time to stop a stream which
+			// won't fit and that chunk_size needs raising
+			fs.Logf(o, "Streaming upload has reached part %d of the %d parts allowed and %v, it will fail if the stream is bigger than %v: increase chunk_size for streams this big",
+				partNum, uploadParts, fs.SizeSuffix(off), streamMaxSize(f.opt.ChunkSize, uploadParts))
+		}
+		g.Go(func() (err error) {
+			defer free()
+			uploadPartReq := objectstorage.UploadPartRequest{
+				NamespaceName:  req.NamespaceName,
+				BucketName:     req.BucketName,
+				ObjectName:     req.Object,
+				UploadId:       uploadID,
+				UploadPartNum:  common.Int(partNum),
+				ContentLength:  common.Int64(int64(len(buf))),
+				OpcSseKmsKeyId: req.OpcSseKmsKeyId,
+			}
+			uploadPartReq.OpcSseCustomerAlgorithm, uploadPartReq.OpcSseCustomerKey, uploadPartReq.OpcSseCustomerKeySha256 = f.sseKey.headers()
+			if !f.opt.DisableChecksum {
+				md5sumBinary := md5.Sum(buf)
+				addMd5(&md5sumBinary, partNum-1)
+				uploadPartReq.ContentMD5 = common.String(base64.StdEncoding.EncodeToString(md5sumBinary[:]))
+			}
+			partCtx := gCtx
+			if !f.opt.DisableCrc32c {
+				partCtx = withExtraHeaders(gCtx, map[string]string{crc32cHeader: crc32cBase64(buf)})
+			}
+			var uploadPartResp objectstorage.UploadPartResponse
+			err = f.pacer.Call(logRetries("UploadPart", func() (bool, error) {
+				chunkCtx, cancel := f.chunkContext(partCtx)
+				defer cancel()
+				uploadPartReq.UploadPartBody = io.NopCloser(bytes.NewReader(buf))
+				uploadPartResp, err = f.srv.UploadPart(chunkCtx, uploadPartReq)
+				return shouldRetry(gCtx, uploadPartResp.HTTPResponse(), err)
+			}))
+			if err != nil {
+				return fmt.Errorf("multipart upload failed to upload part %d: %w", partNum, err)
+			}
+			partsMu.Lock()
+			parts = append(parts, objectstorage.CommitMultipartUploadPartDetails{
+				PartNum: common.Int(partNum),
+				Etag:    uploadPartResp.ETag,
+			})
+			partsMu.Unlock()
+			return nil
+		})
+	}
+	err = g.Wait()
+	if err != nil {
+		return err
+	}
+	if readErr != nil {
+		return readErr
+	}
+
+	// sort the completed parts by part number
+	sort.Slice(parts, func(i, j int) bool {
+		return *parts[i].PartNum < *parts[j].PartNum
+	})
+
+	commitReq := objectstorage.CommitMultipartUploadRequest{
+		NamespaceName: req.NamespaceName,
+		BucketName:    req.BucketName,
+		ObjectName:    req.Object,
+		UploadId:      uploadID,
+		CommitMultipartUploadDetails: objectstorage.CommitMultipartUploadDetails{
+			PartsToCommit: parts,
+		},
+		IfMatch:     req.IfMatch,
+		IfNoneMatch: req.IfNoneMatch,
+	}
+	var commitResp objectstorage.CommitMultipartUploadResponse
+	err = f.pacer.Call(logRetries("CommitMultipartUpload", func() (bool, error) {
+		reqCtx, cancel := f.requestContext(ctx)
+		defer cancel()
+		commitResp, err = f.srv.CommitMultipartUpload(reqCtx, commitReq)
+		return shouldRetryNotIdempotent(ctx, commitResp.HTTPResponse(), err)
+	}))
+	if err != nil {
+		return fmt.Errorf("multipart upload failed to finalise: %w", err)
+	}
+	// the upload is an object now so it mustn't be aborted if it
+	// fails verification
+	f.removeUpload(*uploadID)
+	md5sumBase64 := ""
+	if !f.opt.DisableChecksum {
+		err = checkMultipartMD5(req.Metadata[ociMetaPrefix+metaMD5Hash], hasher.Sum(nil), md5s, commitResp.OpcMultipartMd5)
+		if err != nil {
+			if f.opt.LeavePartsOnError {
+				fs.Errorf(o, "Leaving corrupted object: %v", err)
+			} else if removeErr := o.Remove(ctx); removeErr != nil {
+				fs.Errorf(o, "Failed to remove corrupted object: %v", removeErr)
+			}
+			return err
+		}
+		md5sumBase64 = base64.StdEncoding.EncodeToString(hasher.Sum(nil))
+	}
+	if f.opt.NoHead {
+		return o.setMetaDataFromCommit(req, &commitResp, off, md5sumBase64)
+	}
+	return nil
+}
+
+// retryCorruptedUpload uploads src again once, if it is an object
+// which can be opened again, after the multipart upload of it failed
+// verification with uploadErr
+func (o *Object) retryCorruptedUpload(ctx context.Context, req *objectstorage.CreateMultipartUploadRequest, size int64, src fs.ObjectInfo, uploadErr error) (err error) {
+	srcObj := fs.UnWrapObjectInfo(src)
+	if srcObj == nil {
+		fs.Debugf(o, "Can't retry upload as the source can't be opened again")
+		return uploadErr
+	}
+	in, err := srcObj.Open(ctx)
+	if err != nil {
+		fs.Debugf(o, "Can't retry upload as the source can't be opened again: %v", err)
+		return uploadErr
+	}
+	defer fs.CheckClose(in, &err)
+	fs.Logf(o, "Retrying upload once after checksum mismatch: %v", uploadErr)
+	return o.uploadMultipart(ctx, req, size, in)
+}
+
+// activeUpload is a multipart upload in progress
+type activeUpload struct {
+	o          *Object
+	bucketName string
+	bucketPath string
+}
+
+// addUpload records that the multipart upload uploadID of o has
+// started so it can be aborted by Shutdown
+func (f *Fs) addUpload(o *Object, bucketName, bucketPath, uploadID string) {
+	f.uploadsMu.Lock()
+	defer f.uploadsMu.Unlock()
+	f.uploads[uploadID] = activeUpload{o: o, bucketName: bucketName, bucketPath: bucketPath}
+}
+
+// removeUpload records that the multipart upload uploadID has finished
+func (f *Fs) removeUpload(uploadID string) {
+	f.uploadsMu.Lock()
+	defer f.uploadsMu.Unlock()
+	delete(f.uploads, uploadID)
+}
+
+// abortUpload aborts the multipart upload uploadID if it is still in
+// progress, unless --oos-leave-parts-on-error is set.
+//
+// It is called when an upload fails, at exit and by Shutdown and only
+// aborts each upload once.
+func (f *Fs) abortUpload(ctx context.Context, uploadID string) error {
+	f.uploadsMu.Lock()
+	upload, ok := f.uploads[uploadID]
+	delete(f.uploads, uploadID)
+	f.uploadsMu.Unlock()
+	if !ok || f.opt.LeavePartsOnError {
+		return nil
+	}
+	fs.Debugf(upload.o, "Cancelling multipart upload")
+	err := f.abortMultiPartUpload(ctx, upload.bucketName, upload.bucketPath, uploadID)
+	if err != nil {
+		fs.Debugf(upload.o, "Failed to cancel multipart upload: %v", err)
+		return fmt.Errorf("failed to cancel multipart upload of %v: %w", upload.o, err)
+	}
+	return nil
+}
+
+// errMultipartCorrupted is returned when a committed multipart upload
+// isn't what was read from the source
+var errMultipartCorrupted = errors.New("multipart upload corrupted")
+
+// checkMultipartMD5 checks a committed multipart upload was what was
+// read from the source.
+//
+// srcMD5 is the base64 MD5 of the source if known, objectMD5 the MD5
+// of the data read and partMD5s the MD5s of the parts uploaded in
+// order. multipartMD5 is the base64 MD5 of the part MD5s that object
+// storage assembled the object from followed by "-" and the number of
+// parts, if returned.
+func checkMultipartMD5(srcMD5 string, objectMD5 []byte, partMD5s []byte, multipartMD5 *string) error {
+	if srcMD5 != "" {
+		if got := base64.StdEncoding.EncodeToString(objectMD5); got != srcMD5 {
+			return fmt.Errorf("%w: md5 of data read %q doesn't match source %q", errMultipartCorrupted, got, srcMD5)
+		}
+	}
+	if multipartMD5 != nil {
+		hashOfHashes := md5.Sum(partMD5s)
+		want := base64.StdEncoding.EncodeToString(hashOfHashes[:]) + "-" + strconv.Itoa(len(partMD5s)/md5.Size)
+		if *multipartMD5 != want {
+			return fmt.Errorf("%w: multipart md5 %q doesn't match parts uploaded %q", errMultipartCorrupted, *multipartMD5, want)
+		}
+	}
+	return nil
+}
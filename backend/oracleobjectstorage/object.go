@@ -4,9 +4,12 @@
 package oracleobjectstorage
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -18,10 +21,10 @@ import (
 	"github.com/ncw/swift/v2"
 	"github.com/oracle/oci-go-sdk/v65/common"
 	"github.com/oracle/oci-go-sdk/v65/objectstorage"
-	"github.com/oracle/oci-go-sdk/v65/objectstorage/transfer"
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fserrors"
 	"github.com/rclone/rclone/fs/hash"
-	"github.com/rclone/rclone/lib/atexit"
+	"github.com/rclone/rclone/lib/readers"
 )
 
 // ------------------------------------------------------------
@@ -29,8 +32,9 @@ import (
 // ------------------------------------------------------------
 
 const (
-	metaMtime   = "mtime"     // the meta key to store mtime in - e.g. X-Amz-Meta-Mtime
-	metaMD5Hash = "md5chksum" // the meta key to store md5hash in
+	metaMtime   = "mtime"         // the meta key to store mtime in - e.g. X-Amz-Meta-Mtime
+	metaMD5Hash = "md5chksum"     // the meta key to store md5hash in
+	metaRunID   = "rclone-run-id" // the meta key to store run_id in
 	// StandardTier object storage tier
 	ociMetaPrefix = "opc-meta-"
 )
@@ -47,15 +51,23 @@ var storageTierMap = map[string]*string{
 
 var matchMd5 = regexp.MustCompile(`^[0-9a-f]{32}$`)
 
+// ErrorNotModified is returned by Open when it was given an
+// If-None-Match or If-Modified-Since header and the object is unchanged
+var ErrorNotModified = errors.New("object not modified")
+
 // Object describes a oci bucket object
 type Object struct {
 	fs           *Fs               // what this object is part of
 	remote       string            // The remote path
 	md5          string            // MD5 hash if known
+	crc32c       string            // CRC32C hash if known
+	multipartMD5 bool              // set if listed with a multipart MD5 which isn't comparable
 	bytes        int64             // Size of the object
 	lastModified time.Time         // The modified time of the object if known
 	meta         map[string]string // The object metadata if known - may be nil
 	mimeType     string            // Content-Type of the object
+	etag         string            // ETag of the object if known, changes whenever it is written
+	encoding     string            // Content-Encoding of the object
 
 	// Metadata as pointers to strings as they often won't be present
 	storageTier *string // e.g. Standard
@@ -81,45 +93,83 @@ func (o *Object) readMetaData(ctx context.Context) (err error) {
 
 // headObject gets the metadata from the object unconditionally
 func (o *Object) headObject(ctx context.Context) (info *objectstorage.HeadObjectResponse, err error) {
+	return o.headObjectRetryNotFound(ctx, 0)
+}
+
+// headObjectRetryNotFound gets the metadata from the object
+// unconditionally, retrying up to notFoundRetries times if it isn't
+// found.
+//
+// This is for objects which have only just been written, as these may
+// not be visible straight away.
+func (o *Object) headObjectRetryNotFound(ctx context.Context, notFoundRetries int) (info *objectstorage.HeadObjectResponse, err error) {
 	bucketName, objectPath := o.split()
 	req := objectstorage.HeadObjectRequest{
 		NamespaceName: common.String(o.fs.opt.Namespace),
 		BucketName:    common.String(bucketName),
 		ObjectName:    common.String(objectPath),
 	}
+	req.OpcSseCustomerAlgorithm, req.OpcSseCustomerKey, req.OpcSseCustomerKeySha256 = o.fs.sseKey.headers()
 	var response objectstorage.HeadObjectResponse
-	err = o.fs.pacer.Call(func() (bool, error) {
+	notFound := 0
+	err = o.fs.pacer.Call(logRetries("HeadObject", func() (bool, error) {
+		reqCtx, cancel := o.fs.requestContext(ctx)
+		defer cancel()
 		var err error
-		response, err = o.fs.srv.HeadObject(ctx, req)
+		response, err = o.fs.srv.HeadObject(reqCtx, req)
+		if svcErr, ok := err.(common.ServiceError); ok && svcErr.GetHTTPStatusCode() == http.StatusNotFound && notFound < notFoundRetries {
+			notFound++
+			fs.Debugf(o, "Object not found yet, retrying %d/%d", notFound, notFoundRetries)
+			return true, err
+		}
 		return shouldRetry(ctx, response.HTTPResponse(), err)
-	})
+	}))
 	if err != nil {
-		if svcErr, ok := err.(common.ServiceError); ok {
-			if svcErr.GetHTTPStatusCode() == http.StatusNotFound {
-				return nil, fs.ErrorObjectNotFound
-			}
-		}
-		return nil, err
+		return nil, objectError(err, bucketName, objectPath)
 	}
 	o.fs.cache.MarkOK(bucketName)
 	return &response, err
 }
 
+// objectError translates err from reading the object bucketPath in
+// bucketName. It returns fs.ErrorObjectNotFound only if object storage
+// said the object wasn't found, so a lack of permission isn't mistaken
+// for a missing object which then gets uploaded.
+func objectError(err error, bucketName, bucketPath string) error {
+	var svcErr common.ServiceError
+	if errors.As(err, &svcErr) && !isWrongHost(err) {
+		switch svcErr.GetHTTPStatusCode() {
+		case http.StatusNotFound:
+			return fs.ErrorObjectNotFound
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return fmt.Errorf("%w: not authorized to read %q in bucket %q, check the policies allow reading objects: %v",
+				fs.ErrorPermissionDenied, bucketPath, bucketName, err)
+		}
+	}
+	return err
+}
+
 func (o *Object) decodeMetaDataHead(info *objectstorage.HeadObjectResponse) (err error) {
+	o.crc32c = crc32cFromResponse(info.RawResponse)
+	o.setETag(info.ETag)
 	return o.setMetaData(
 		info.ContentLength,
 		info.ContentMd5,
 		info.ContentType,
+		info.ContentEncoding,
 		info.LastModified,
 		info.StorageTier,
 		info.OpcMeta)
 }
 
 func (o *Object) decodeMetaDataObject(info *objectstorage.GetObjectResponse) (err error) {
+	o.crc32c = crc32cFromResponse(info.RawResponse)
+	o.setETag(info.ETag)
 	return o.setMetaData(
 		info.ContentLength,
 		info.ContentMd5,
 		info.ContentType,
+		info.ContentEncoding,
 		info.LastModified,
 		info.StorageTier,
 		info.OpcMeta)
@@ -129,6 +179,7 @@ func (o *Object) setMetaData(
 	contentLength *int64,
 	contentMd5 *string,
 	contentType *string,
+	contentEncoding *string,
 	lastModified *common.SDKTime,
 	storageTier interface{},
 	meta map[string]string) error {
@@ -146,10 +197,11 @@ func (o *Object) setMetaData(
 	if o.meta == nil {
 		o.meta = map[string]string{}
 	}
-	// Read MD5 from metadata if present
+	// Read MD5 from metadata if present, which multipart uploads
+	// store as they have no MD5 of the whole object
 	if md5sumBase64, ok := o.meta[metaMD5Hash]; ok {
 		md5, err := o.base64ToMd5(md5sumBase64)
-		if err != nil {
+		if err == nil {
 			o.md5 = md5
 		}
 	}
@@ -162,6 +214,9 @@ func (o *Object) setMetaData(
 	if contentType != nil {
 		o.mimeType = *contentType
 	}
+	if contentEncoding != nil {
+		o.encoding = *contentEncoding
+	}
 	if storageTier == nil || storageTier == "" {
 		o.storageTier = storageTierMap[standard]
 	} else {
@@ -171,6 +226,13 @@ func (o *Object) setMetaData(
 	return nil
 }
 
+// isMultipartMD5 returns true if md5sumBase64 is the MD5 object
+// storage lists for multipart objects, the base64 MD5 of the MD5s of
+// the parts followed by "-" and the number of parts
+func isMultipartMD5(md5sumBase64 string) bool {
+	return strings.Contains(md5sumBase64, "-")
+}
+
 func (o *Object) base64ToMd5(md5sumBase64 string) (md5 string, err error) {
 	md5sumBytes, err := base64.StdEncoding.DecodeString(md5sumBase64)
 	if err != nil {
@@ -208,6 +270,13 @@ func (o *Object) Size() int64 {
 
 // GetTier returns storage class as string
 func (o *Object) GetTier() string {
+	if o.storageTier == nil && !o.fs.listFields["storageTier"] {
+		// the tier wasn't read by the listing
+		err := o.readMetaData(context.TODO())
+		if err != nil {
+			fs.Logf(o, "Failed to read metadata: %v", err)
+		}
+	}
 	if o.storageTier == nil || *o.storageTier == "" {
 		return standard
 	}
@@ -240,6 +309,27 @@ func (o *Object) SetTier(tier string) (err error) {
 	return err
 }
 
+// setETag sets the etag of the object if etag is set
+func (o *Object) setETag(etag *string) {
+	if etag != nil {
+		o.etag = *etag
+	}
+}
+
+// ETag returns the etag of the object if known, "" otherwise.
+//
+// Object storage makes a new etag whenever an object is written, by
+// an upload or a server-side copy, so it can be used to detect changes
+// to objects which don't have an MD5, such as multipart uploads.
+func (o *Object) ETag() string {
+	return o.etag
+}
+
+// ID returns the etag of the object if known, "" otherwise
+func (o *Object) ID() string {
+	return o.etag
+}
+
 // MimeType of an Object if known, "" otherwise
 func (o *Object) MimeType(ctx context.Context) string {
 	err := o.readMetaData(ctx)
@@ -250,13 +340,27 @@ func (o *Object) MimeType(ctx context.Context) string {
 	return o.mimeType
 }
 
-// Hash returns the MD5 of an object returning a lowercase hex string
+// Hash returns the MD5 or CRC32C of an object returning a lowercase hex string
+//
+// Object storage has no MD5 of the data of multipart objects, only
+// the MD5 of the MD5s of the parts, so their MD5 is empty unless
+// rclone stored it in the metadata when uploading and the object was
+// read with a HEAD rather than listed.
 func (o *Object) Hash(ctx context.Context, t hash.Type) (string, error) {
+	if t == CRC32CHashType && !o.fs.opt.DisableCrc32c {
+		if o.crc32c == "" {
+			err := o.readMetaData(ctx)
+			if err != nil {
+				return "", err
+			}
+		}
+		return o.crc32c, nil
+	}
 	if t != hash.MD5 {
 		return "", hash.ErrUnsupported
 	}
 	// Convert base64 encoded md5 into lower case hex
-	if o.md5 == "" {
+	if o.md5 == "" && !o.multipartMD5 {
 		err := o.readMetaData(ctx)
 		if err != nil {
 			return "", err
@@ -271,6 +375,14 @@ func (o *Object) Hash(ctx context.Context, t hash.Type) (string, error) {
 // LastModified returned to the http headers
 func (o *Object) ModTime(ctx context.Context) (result time.Time) {
 	if o.fs.ci.UseServerModTime {
+		if o.lastModified.IsZero() {
+			// the modification time wasn't read by the listing
+			err := o.readMetaData(ctx)
+			if err != nil {
+				fs.Logf(o, "Failed to read metadata: %v", err)
+				return time.Now()
+			}
+		}
 		return o.lastModified
 	}
 	err := o.readMetaData(ctx)
@@ -293,12 +405,18 @@ func (o *Object) ModTime(ctx context.Context) (result time.Time) {
 
 // SetModTime sets the modification time of the local fs object
 func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	if o.fs.opt.DisableServerSideCopy {
+		return fs.ErrorCantSetModTime
+	}
 	err := o.readMetaData(ctx)
 	if err != nil {
 		return err
 	}
 	o.meta[metaMtime] = swift.TimeToFloatString(modTime)
-	_, err = o.fs.Copy(ctx, o, o.remote)
+	_, err = o.fs.copyObject(ctx, o, o.remote, o.replacementMetadata(nil), o.fs.opt.PreserveTier)
+	if errors.Is(err, fs.ErrorCantCopy) {
+		return fs.ErrorCantSetModTime
+	}
 	return err
 }
 
@@ -310,37 +428,70 @@ func (o *Object) Storable() bool {
 // Remove an object
 func (o *Object) Remove(ctx context.Context) error {
 	bucketName, bucketPath := o.split()
+	o.fs.listCache.forget(bucketName, bucketPath)
 	req := objectstorage.DeleteObjectRequest{
 		NamespaceName: common.String(o.fs.opt.Namespace),
 		BucketName:    common.String(bucketName),
 		ObjectName:    common.String(bucketPath),
 	}
-	err := o.fs.pacer.Call(func() (bool, error) {
-		resp, err := o.fs.srv.DeleteObject(ctx, req)
+	err := o.fs.pacer.Call(logRetries("DeleteObject", func() (bool, error) {
+		reqCtx, cancel := o.fs.requestContext(ctx)
+		defer cancel()
+		resp, err := o.fs.srv.DeleteObject(reqCtx, req)
 		return shouldRetry(ctx, resp.HTTPResponse(), err)
-	})
-	return err
+	}))
+	return o.retentionError(ctx, err)
 }
 
 // Open object file
 func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	if o.fs.opt.DownloadConcurrency > 1 && len(options) == 0 {
+		err := o.readMetaData(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if o.canDownloadConcurrently() {
+			o.warnRetrievalCost()
+			return o.newConcurrentReader(ctx), nil
+		}
+	}
 	bucketName, bucketPath := o.split()
 	req := objectstorage.GetObjectRequest{
 		NamespaceName: common.String(o.fs.opt.Namespace),
 		BucketName:    common.String(bucketName),
 		ObjectName:    common.String(bucketPath),
 	}
-	o.applyGetObjectOptions(&req, options...)
+	req.OpcSseCustomerAlgorithm, req.OpcSseCustomerKey, req.OpcSseCustomerKeySha256 = o.fs.sseKey.headers()
+	headers := o.applyGetObjectOptions(&req, options...)
+	if o.rangePastEnd(options) {
+		// nothing to read so don't ask for an invalid range
+		return io.NopCloser(strings.NewReader("")), nil
+	}
 
+	getCtx := ctx
+	if len(headers) > 0 {
+		getCtx = withExtraHeaders(ctx, headers)
+	}
 	var resp objectstorage.GetObjectResponse
-	err := o.fs.pacer.Call(func() (bool, error) {
+	err := o.fs.pacer.Call(logRetries("GetObject", func() (bool, error) {
 		var err error
-		resp, err = o.fs.srv.GetObject(ctx, req)
+		resp, err = o.fs.srv.GetObject(getCtx, req)
 		return shouldRetry(ctx, resp.HTTPResponse(), err)
-	})
+	}))
 	if err != nil {
+		if svcErr, ok := err.(common.ServiceError); ok && req.Range != nil {
+			if svcErr.GetHTTPStatusCode() == http.StatusRequestedRangeNotSatisfiable {
+				// the object is shorter than we thought
+				return io.NopCloser(strings.NewReader("")), nil
+			}
+		}
 		return nil, err
 	}
+	if resp.HTTPResponse().StatusCode == http.StatusNotModified {
+		// the SDK doesn't treat 304 as an error
+		_ = resp.HTTPResponse().Body.Close()
+		return nil, ErrorNotModified
+	}
 	// read size from ContentLength or ContentRange
 	bytes := resp.ContentLength
 	if resp.ContentRange != nil {
@@ -361,26 +512,79 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadClo
 	if err != nil {
 		return nil, err
 	}
-	o.bytes = *bytes
-	return resp.HTTPResponse().Body, nil
+	o.warnRetrievalCost()
+	if bytes != nil {
+		o.bytes = *bytes
+	}
+	body := resp.HTTPResponse().Body
+	if o.fs.opt.MightGzip && resp.ContentLength == nil && req.Range == nil {
+		// the object may have been compressed or decompressed on
+		// the way so the size and hashes of what is read aren't
+		// known
+		o.bytes = -1
+		o.md5 = ""
+		o.crc32c = ""
+		if resp.ContentEncoding != nil && strings.EqualFold(*resp.ContentEncoding, "gzip") {
+			fs.Debugf(o, "Decompressing chunked 'Content-Encoding: gzip' download")
+			return readers.NewGzipReader(body)
+		}
+	}
+	return body, nil
+}
+
+// warnRetrievalCost warns once per remote, if --oos-warn-retrieval-cost
+// is set, that reading o costs extra because of its storage tier
+func (o *Object) warnRetrievalCost() {
+	if !o.fs.opt.WarnRetrievalCost || o.storageTier == nil {
+		return
+	}
+	tier := *o.storageTier
+	if tier != infrequentAccess && tier != archive {
+		return
+	}
+	o.fs.warnRetrieval.Do(func() {
+		fs.Logf(o, "Reading objects in the %s storage tier incurs retrieval costs", tier)
+	})
 }
 
 // Update an object if it has changed
 func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (err error) {
 	bucketName, bucketPath := o.split()
+	o.fs.listCache.forget(bucketName, bucketPath)
+	meta, err := fs.GetMetadataOptions(ctx, src, options)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata from source object: %w", err)
+	}
+	storageTier, err := o.uploadStorageTier(meta)
+	if err != nil {
+		return err
+	}
+	ifMatch, ifNoneMatch := o.uploadConditions()
 	err = o.fs.makeBucket(ctx, bucketName)
 	if err != nil {
 		return err
 	}
 
 	// determine if we like upload single or multipart.
+	//
+	// This uses the size of src even if in can't tell its length so
+	// the upload is only streamed if the size really isn't known.
 	size := src.Size()
-	multipart := size >= int64(o.fs.opt.UploadCutoff)
+	if size < 0 {
+		// streams which fit in one chunk are uploaded in a single part
+		// so an empty stream makes an empty object
+		in, size, err = bufferStream(in, o.fs.opt.ChunkSize)
+		if err != nil {
+			return fmt.Errorf("failed to read upload: %w", err)
+		}
+	}
+	multipart := o.fs.useMultipart(size)
 
-	// Set the mtime in the metadata
-	modTime := src.ModTime(ctx)
-	metadata := map[string]string{
-		metaMtime: swift.TimeToFloatString(modTime),
+	// Set the mtime and any rclone metadata in the metadata
+	metadata, headers := o.uploadMetadata(meta, src.ModTime(ctx))
+	options = append(headers, options...)
+	if o.fs.opt.RunID != "" {
+		metadata[metaRunID] = o.fs.opt.RunID
 	}
 
 	// read the md5sum if available
@@ -411,108 +615,122 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	mimeType := fs.MimeType(ctx, src)
 
 	if multipart {
-		chunkSize := int64(o.fs.opt.ChunkSize)
-		uploadRequest := transfer.UploadRequest{
-			NamespaceName:                       common.String(o.fs.opt.Namespace),
-			BucketName:                          common.String(bucketName),
-			ObjectName:                          common.String(bucketPath),
-			ContentType:                         common.String(mimeType),
-			PartSize:                            common.Int64(chunkSize),
-			AllowMultipartUploads:               common.Bool(true),
-			AllowParrallelUploads:               common.Bool(true),
-			ObjectStorageClient:                 o.fs.srv,
-			EnableMultipartChecksumVerification: common.Bool(!o.fs.opt.DisableChecksum),
-			NumberOfGoroutines:                  common.Int(o.fs.opt.UploadConcurrency),
-			Metadata:                            metadataWithOpcPrefix(metadata),
-		}
-		if o.fs.opt.StorageTier != "" {
-			storageTier, ok := objectstorage.GetMappingPutObjectStorageTierEnum(o.fs.opt.StorageTier)
-			if !ok {
-				return fmt.Errorf("not a valid storage tier: %v", o.fs.opt.StorageTier)
-			}
-			uploadRequest.StorageTier = storageTier
+		req := objectstorage.CreateMultipartUploadRequest{
+			NamespaceName: common.String(o.fs.opt.Namespace),
+			BucketName:    common.String(bucketName),
+			CreateMultipartUploadDetails: objectstorage.CreateMultipartUploadDetails{
+				Object:      common.String(bucketPath),
+				ContentType: common.String(mimeType),
+				Metadata:    metadataWithOpcPrefix(metadata),
+			},
+			OpcSseKmsKeyId: o.fs.kmsKeyID(bucketName),
+			IfMatch:        ifMatch,
+			IfNoneMatch:    ifNoneMatch,
 		}
-		o.applyMultiPutOptions(&uploadRequest, options...)
-		uploadStreamRequest := transfer.UploadStreamRequest{
-			UploadRequest: uploadRequest,
-			StreamReader:  in,
+		req.OpcSseCustomerAlgorithm, req.OpcSseCustomerKey, req.OpcSseCustomerKeySha256 = o.fs.sseKey.headers()
+		if storageTier != "" {
+			req.StorageTier, _ = objectstorage.GetMappingStorageTierEnum(storageTier)
+		}
+		o.applyMultiPutOptions(&req.CreateMultipartUploadDetails, options...)
+		err = o.uploadMultipart(ctx, &req, size, in)
+		if errors.Is(err, errMultipartCorrupted) && o.fs.opt.AttemptResumeOnMismatch {
+			err = o.retryCorruptedUpload(ctx, &req, size, src, err)
 		}
-		uploadMgr := transfer.NewUploadManager()
-		var uploadID = ""
-
-		defer atexit.OnError(&err, func() {
-			if uploadID == "" {
-				return
-			}
-			if o.fs.opt.LeavePartsOnError {
-				return
-			}
-			fs.Debugf(o, "Cancelling multipart upload")
-			errCancel := o.fs.abortMultiPartUpload(
-				context.Background(),
-				bucketName,
-				bucketPath,
-				uploadID)
-			if errCancel != nil {
-				fs.Debugf(o, "Failed to cancel multipart upload: %v", errCancel)
-			}
-		})()
-
-		err = o.fs.pacer.Call(func() (bool, error) {
-			uploadResponse, err := uploadMgr.UploadStream(ctx, uploadStreamRequest)
-			var httpResponse *http.Response
-			if err == nil {
-				if uploadResponse.Type == transfer.MultipartUpload {
-					if uploadResponse.MultipartUploadResponse != nil {
-						httpResponse = uploadResponse.MultipartUploadResponse.HTTPResponse()
-					}
-				} else {
-					if uploadResponse.SinglepartUploadResponse != nil {
-						httpResponse = uploadResponse.SinglepartUploadResponse.HTTPResponse()
-					}
-				}
-			}
-			if err != nil {
-				uploadID := ""
-				if uploadResponse.MultipartUploadResponse != nil && uploadResponse.MultipartUploadResponse.UploadID != nil {
-					uploadID = *uploadResponse.MultipartUploadResponse.UploadID
-					fs.Debugf(o, "multipart streaming upload failed, aborting uploadID: %v, may retry", uploadID)
-					_ = o.fs.abortMultiPartUpload(ctx, bucketName, bucketPath, uploadID)
-				}
-			}
-			return shouldRetry(ctx, httpResponse, err)
-		})
 		if err != nil {
 			fs.Errorf(o, "multipart streaming upload failed %v", err)
-			return err
+			return o.retentionError(ctx, o.conditionError(err, ifMatch, ifNoneMatch))
+		}
+		if o.fs.opt.NoHead {
+			// uploadMultipart set the metadata from the commit
+			return nil
 		}
 	} else {
+		var free func()
+		if !o.fs.opt.DisableChecksum && md5sumBase64 == "" {
+			in, md5sumBase64, free, err = o.fs.md5Body(in, size)
+			if err != nil {
+				return fmt.Errorf("failed to read upload: %w", err)
+			}
+			defer free()
+		}
 		req := objectstorage.PutObjectRequest{
-			NamespaceName: common.String(o.fs.opt.Namespace),
-			BucketName:    common.String(bucketName),
-			ObjectName:    common.String(bucketPath),
-			ContentType:   common.String(mimeType),
-			PutObjectBody: io.NopCloser(in),
-			OpcMeta:       metadata,
+			NamespaceName:  common.String(o.fs.opt.Namespace),
+			BucketName:     common.String(bucketName),
+			ObjectName:     common.String(bucketPath),
+			ContentType:    common.String(mimeType),
+			PutObjectBody:  io.NopCloser(in),
+			OpcMeta:        metadata,
+			OpcSseKmsKeyId: o.fs.kmsKeyID(bucketName),
+			IfMatch:        ifMatch,
+			IfNoneMatch:    ifNoneMatch,
 		}
+		req.OpcSseCustomerAlgorithm, req.OpcSseCustomerKey, req.OpcSseCustomerKeySha256 = o.fs.sseKey.headers()
 		if size >= 0 {
 			req.ContentLength = common.Int64(size)
 		}
-		if o.fs.opt.StorageTier != "" {
-			storageTier, ok := objectstorage.GetMappingPutObjectStorageTierEnum(o.fs.opt.StorageTier)
-			if !ok {
-				return fmt.Errorf("not a valid storage tier: %v", o.fs.opt.StorageTier)
-			}
-			req.StorageTier = storageTier
+		if !o.fs.opt.DisableChecksum && md5sumBase64 != "" {
+			// object storage rejects the upload if it was corrupted
+			req.ContentMD5 = common.String(md5sumBase64)
+		}
+		if storageTier != "" {
+			req.StorageTier, _ = objectstorage.GetMappingPutObjectStorageTierEnum(storageTier)
 		}
 		o.applyPutOptions(&req, options...)
-		err = o.fs.pacer.Call(func() (bool, error) {
-			resp, err := o.fs.srv.PutObject(ctx, req)
-			return shouldRetry(ctx, resp.HTTPResponse(), err)
-		})
+		// the SDK has no field for the CRC32C so it is sent as an extra header
+		putCtx := ctx
+		var crc32c string
+		if !o.fs.opt.DisableCrc32c {
+			crc32c, _ = src.Hash(ctx, CRC32CHashType)
+			if crc32cBase64 := crc32cToBase64(crc32c); crc32cBase64 != "" {
+				putCtx = withExtraHeaders(ctx, map[string]string{crc32cHeader: crc32cBase64})
+			} else {
+				crc32c = ""
+			}
+		}
+		var (
+			resp      objectstorage.PutObjectResponse
+			retryBody io.ReadCloser // the body sent by the last retry
+		)
+		defer func() {
+			if retryBody != nil {
+				_ = retryBody.Close()
+			}
+		}()
+		err = o.fs.pacer.Call(logRetries("PutObject", func() (bool, error) {
+			resp, err = o.fs.srv.PutObject(putCtx, req)
+			// if the data was corrupted on the way send it again
+			mismatch := isContentMD5Mismatch(err)
+			retry := mismatch
+			if !mismatch {
+				retry, err = shouldRetry(ctx, resp.HTTPResponse(), err)
+			}
+			if !retry {
+				return false, err
+			}
+			// some of the body may have been sent already so it
+			// has to be sent again from the start
+			body, rewindErr := o.rewindBody(ctx, in, src)
+			if rewindErr != nil {
+				fs.Debugf(o, "Can't retry upload: %v", rewindErr)
+				return false, err
+			}
+			if retryBody != nil {
+				_ = retryBody.Close()
+			}
+			retryBody = body
+			req.PutObjectBody = body
+			if mismatch {
+				fs.Debugf(o, "Retrying upload after Content-MD5 mismatch: %v", err)
+			}
+			return true, err
+		}))
 		if err != nil {
 			fs.Errorf(o, "put object failed %v", err)
-			return err
+			return o.retentionError(ctx, o.conditionError(err, ifMatch, ifNoneMatch))
+		}
+		if o.fs.opt.NoHead && size >= 0 {
+			o.crc32c = crc32c
+			return o.setMetaDataFromPut(&req, &resp, md5sumBase64)
 		}
 	}
 	// Read the metadata from the newly created object
@@ -520,6 +738,262 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	return o.readMetaData(ctx)
 }
 
+// rewindBody returns the upload in, read from src, to send again from
+// the start. It is rewound if it can be, otherwise src is opened again
+// if it is an object.
+func (o *Object) rewindBody(ctx context.Context, in io.Reader, src fs.ObjectInfo) (io.ReadCloser, error) {
+	if seeker, ok := in.(io.Seeker); ok {
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind source: %w", err)
+		}
+		return io.NopCloser(in), nil
+	}
+	srcObj := fs.UnWrapObjectInfo(src)
+	if srcObj == nil {
+		return nil, errors.New("source can't be rewound or opened again")
+	}
+	body, err := srcObj.Open(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open source again: %w", err)
+	}
+	return body, nil
+}
+
+// uploadConditions returns the If-Match and If-None-Match headers to
+// upload o with for the upload_condition option.
+//
+// Objects being created have no ETag so they are only uploaded if
+// they don't exist.
+func (o *Object) uploadConditions() (ifMatch, ifNoneMatch *string) {
+	switch {
+	case o.fs.opt.UploadCondition == uploadConditionIfUnchanged && o.etag != "":
+		return common.String(o.etag), nil
+	case o.fs.opt.UploadCondition != "":
+		return nil, common.String("*")
+	}
+	return nil, nil
+}
+
+// conditionError returns an error saying which precondition failed,
+// which isn't retried, if err is because the upload was sent with
+// ifMatch or ifNoneMatch and they didn't match
+func (o *Object) conditionError(err error, ifMatch, ifNoneMatch *string) error {
+	var serviceErr common.ServiceError
+	if !errors.As(err, &serviceErr) || serviceErr.GetHTTPStatusCode() != http.StatusPreconditionFailed {
+		return err
+	}
+	switch {
+	case ifMatch != nil:
+		err = fmt.Errorf("precondition failed: %v has changed since it was read with ETag %s: %w", o, *ifMatch, err)
+	case ifNoneMatch != nil:
+		err = fmt.Errorf("precondition failed: %v exists already: %w", o, err)
+	default:
+		return err
+	}
+	return fserrors.NoRetryError(err)
+}
+
+// useMultipart returns true if an upload of size bytes, or -1 if the
+// size isn't known, should be a multipart upload
+func (f *Fs) useMultipart(size int64) bool {
+	switch {
+	case size < 0:
+		return true
+	case size == 0:
+		// a multipart upload of nothing would need an empty part
+		return false
+	case f.opt.ForceMultipart:
+		return true
+	case size >= int64(f.opt.UploadCutoff):
+		return true
+	case size > int64(maxUploadCutoff):
+		// object storage rejects single part uploads this big
+		fs.Infof(f, "Using a multipart upload for %v as it is over the %v limit of a single part upload, whatever upload_cutoff %v is",
+			fs.SizeSuffix(size), maxUploadCutoff, f.opt.UploadCutoff)
+		return true
+	}
+	return false
+}
+
+// md5Body returns the base64 MD5 of an upload of size bytes which is
+// to be sent in a single part along with a reader of it.
+//
+// Uploads which can be rewound are read to calculate it then rewound
+// and uploads which fit in a chunk are read into memory from the pool,
+// which is returned with free, so they can be sent again. Other
+// uploads are returned unchanged without an MD5.
+func (f *Fs) md5Body(in io.Reader, size int64) (body io.Reader, md5sumBase64 string, free func(), err error) {
+	free = func() {}
+	hasher := md5.New()
+	if seeker, ok := in.(io.ReadSeeker); ok {
+		if _, err = io.Copy(hasher, seeker); err != nil {
+			return nil, "", free, err
+		}
+		if _, err = seeker.Seek(0, io.SeekStart); err != nil {
+			return nil, "", free, err
+		}
+		return in, base64.StdEncoding.EncodeToString(hasher.Sum(nil)), free, nil
+	}
+	if size < 0 || size > int64(f.opt.ChunkSize) {
+		return in, "", free, nil
+	}
+	buf := f.pool.Get()
+	free = func() { f.pool.Put(buf) }
+	buf = buf[:size]
+	if _, err = io.ReadFull(in, buf); err != nil {
+		return nil, "", free, err
+	}
+	_, _ = hasher.Write(buf)
+	return bytes.NewReader(buf), base64.StdEncoding.EncodeToString(hasher.Sum(nil)), free, nil
+}
+
+// uploadStorageTier returns the storage tier to upload the object
+// with. This is the storage_tier option unless overridden by a "tier"
+// or "storage-tier" key in meta, the metadata when --metadata is in use.
+func (o *Object) uploadStorageTier(meta fs.Metadata) (string, error) {
+	storageTier := o.fs.opt.StorageTier
+	for k, v := range meta {
+		switch strings.ToLower(k) {
+		case "tier", "storage-tier":
+			storageTier = v
+		}
+	}
+	if storageTier == "" {
+		return "", nil
+	}
+	// both enums have the same values but check both as multipart
+	// uploads and single part uploads use different ones
+	_, okMultipart := objectstorage.GetMappingStorageTierEnum(storageTier)
+	_, okPut := objectstorage.GetMappingPutObjectStorageTierEnum(storageTier)
+	if !okMultipart || !okPut {
+		return "", fmt.Errorf("not a valid storage tier: %v", storageTier)
+	}
+	return storageTier, nil
+}
+
+// uploadMetadata splits meta, the metadata when --metadata is in use,
+// into the user metadata to store as opc-meta- keys and the content
+// headers. These are returned as options to go before those passed to
+// Update so any set with --header-upload take precedence.
+//
+// The mtime is stored as rclone always stores it, taken from meta if
+// it is there or modTime otherwise.
+func (o *Object) uploadMetadata(meta fs.Metadata, modTime time.Time) (metadata map[string]string, headers []fs.OpenOption) {
+	metadata = map[string]string{}
+	for k, v := range meta {
+		lowerKey := strings.ToLower(k)
+		switch lowerKey {
+		case "cache-control", "content-disposition", "content-encoding", "content-language", "content-type":
+			headers = append(headers, &fs.HTTPOption{Key: lowerKey, Value: v})
+		case "tier", "storage-tier":
+			// read by uploadStorageTier
+		case metaRetentionUntil:
+			// read only
+		case "mtime":
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				fs.Debugf(o, "Failed to parse metadata %s: %q: %v", k, v, err)
+			} else {
+				modTime = t
+			}
+		default:
+			metadata[strings.TrimPrefix(lowerKey, ociMetaPrefix)] = v
+		}
+	}
+	metadata[metaMtime] = swift.TimeToFloatString(modTime)
+	return metadata, headers
+}
+
+// Metadata returns metadata for an object
+//
+// It should return nil if there is no Metadata
+func (o *Object) Metadata(ctx context.Context) (metadata fs.Metadata, err error) {
+	err = o.readMetaData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata = make(fs.Metadata, len(o.meta)+3)
+	for k, v := range o.meta {
+		switch k {
+		case metaMtime:
+			if modTime, err := swift.FloatStringToTime(v); err == nil {
+				metadata["mtime"] = modTime.Format(time.RFC3339Nano)
+			}
+		case metaMD5Hash:
+			// don't write hash metadata
+		default:
+			metadata[k] = v
+		}
+	}
+	if o.mimeType != "" {
+		metadata["content-type"] = o.mimeType
+	}
+	if o.encoding != "" {
+		metadata["content-encoding"] = o.encoding
+	}
+	if o.storageTier != nil && *o.storageTier != "" {
+		metadata["tier"] = *o.storageTier
+	}
+	if until := o.retentionMetadata(ctx); until != "" {
+		metadata[metaRetentionUntil] = until
+	}
+	return metadata, nil
+}
+
+// setMetaDataFromPut sets the metadata from the request and response
+// of a successful PutObject rather than doing a HEAD
+func (o *Object) setMetaDataFromPut(req *objectstorage.PutObjectRequest, resp *objectstorage.PutObjectResponse,
+	md5sumBase64 string) error {
+	contentMd5 := resp.OpcContentMd5
+	if contentMd5 == nil && md5sumBase64 != "" {
+		contentMd5 = common.String(md5sumBase64)
+	}
+	lastModified := resp.LastModified
+	if lastModified == nil {
+		lastModified = &common.SDKTime{Time: time.Now()}
+	}
+	o.setETag(resp.ETag)
+	return o.setMetaData(
+		req.ContentLength,
+		contentMd5,
+		req.ContentType,
+		req.ContentEncoding,
+		lastModified,
+		string(req.StorageTier),
+		req.OpcMeta)
+}
+
+// setMetaDataFromCommit sets the metadata from the request and
+// response of a successful multipart upload of size bytes rather than
+// doing a HEAD. md5sumBase64 is the MD5 of the data uploaded if it
+// was calculated.
+func (o *Object) setMetaDataFromCommit(req *objectstorage.CreateMultipartUploadRequest, resp *objectstorage.CommitMultipartUploadResponse,
+	size int64, md5sumBase64 string) error {
+	var contentMd5 *string
+	if md5sumBase64 != "" {
+		contentMd5 = common.String(md5sumBase64)
+	}
+	// without an MD5 a HEAD would only find the multipart MD5
+	o.multipartMD5 = contentMd5 == nil
+	lastModified := resp.LastModified
+	if lastModified == nil {
+		lastModified = &common.SDKTime{Time: time.Now()}
+	}
+	meta := make(map[string]string, len(req.Metadata))
+	for key, value := range req.Metadata {
+		meta[strings.TrimPrefix(key, ociMetaPrefix)] = value
+	}
+	o.setETag(resp.ETag)
+	return o.setMetaData(
+		common.Int64(size),
+		contentMd5,
+		req.ContentType,
+		req.ContentEncoding,
+		lastModified,
+		string(req.StorageTier),
+		meta)
+}
+
 func (o *Object) applyPutOptions(req *objectstorage.PutObjectRequest, options ...fs.OpenOption) {
 	// Apply upload options
 	for _, option := range options {
@@ -540,7 +1014,8 @@ func (o *Object) applyPutOptions(req *objectstorage.PutObjectRequest, options ..
 			req.ContentType = common.String(value)
 		default:
 			if strings.HasPrefix(lowerKey, ociMetaPrefix) {
-				req.OpcMeta[lowerKey] = value
+				// the SDK adds the prefix to the OpcMeta keys itself
+				req.OpcMeta[strings.TrimPrefix(lowerKey, ociMetaPrefix)] = value
 			} else {
 				fs.Errorf(o, "Don't know how to set key %q on upload", key)
 			}
@@ -548,7 +1023,7 @@ func (o *Object) applyPutOptions(req *objectstorage.PutObjectRequest, options ..
 	}
 }
 
-func (o *Object) applyGetObjectOptions(req *objectstorage.GetObjectRequest, options ...fs.OpenOption) {
+func (o *Object) applyGetObjectOptions(req *objectstorage.GetObjectRequest, options ...fs.OpenOption) (headers map[string]string) {
 	fs.FixRangeOption(options, o.bytes)
 	for _, option := range options {
 		switch option.(type) {
@@ -580,13 +1055,33 @@ func (o *Object) applyGetObjectOptions(req *objectstorage.GetObjectRequest, opti
 			req.HttpResponseContentType = common.String(value)
 		case "range":
 			// do nothing
+		case "if-match":
+			req.IfMatch = common.String(value)
+		case "if-none-match":
+			req.IfNoneMatch = common.String(value)
+		case "if-modified-since":
+			// the SDK has no field for it so it is sent as an extra header
+			headers = map[string]string{"If-Modified-Since": value}
 		default:
-			fs.Errorf(o, "Don't know how to set key %q on upload", key)
+			fs.Errorf(o, "Don't know how to set key %q on download", key)
 		}
 	}
+	return headers
 }
 
-func (o *Object) applyMultiPutOptions(req *transfer.UploadRequest, options ...fs.OpenOption) {
+// rangePastEnd returns true if the options, already fixed up by
+// applyGetObjectOptions, ask for a range starting at or beyond the end
+// of the object
+func (o *Object) rangePastEnd(options []fs.OpenOption) bool {
+	for _, option := range options {
+		if x, ok := option.(*fs.RangeOption); ok && o.bytes > 0 && x.Start >= o.bytes {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Object) applyMultiPutOptions(req *objectstorage.CreateMultipartUploadDetails, options ...fs.OpenOption) {
 	// Apply upload options
 	for _, option := range options {
 		key, value := option.Header()
@@ -594,6 +1089,10 @@ func (o *Object) applyMultiPutOptions(req *transfer.UploadRequest, options ...fs
 		switch lowerKey {
 		case "":
 			// ignore
+		case "cache-control":
+			req.CacheControl = common.String(value)
+		case "content-disposition":
+			req.ContentDisposition = common.String(value)
 		case "content-encoding":
 			req.ContentEncoding = common.String(value)
 		case "content-language":
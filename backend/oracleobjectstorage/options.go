@@ -4,8 +4,13 @@
 package oracleobjectstorage
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config"
 	"github.com/rclone/rclone/lib/encoder"
@@ -16,11 +21,27 @@ const (
 	minChunkSize               = fs.SizeSuffix(1024 * 1024 * 5)
 	defaultUploadCutoff        = fs.SizeSuffix(200 * 1024 * 1024)
 	defaultUploadConcurrency   = 10
+	defaultCopyConcurrency     = 4
 	maxUploadCutoff            = fs.SizeSuffix(5 * 1024 * 1024 * 1024)
+	maxUploadParts             = 10000                                  // maximum allowed number of parts in a multipart upload
+	maxChunkSize               = fs.SizeSuffix(50 * 1024 * 1024 * 1024) // maximum size of a part
+	maxAutoUploadConcurrency   = 20                                     // most parts uploaded at once with upload_concurrency auto
+	autoUploadConcurrencySize  = fs.SizeSuffix(64 * 1024 * 1024)        // size of upload per part uploaded at once with upload_concurrency auto
+	maxListChunk               = 1000                                   // maximum number of objects returned by a listing request
 	minSleep                   = 100 * time.Millisecond
 	maxSleep                   = 5 * time.Minute
 	decayConstant              = 1 // bigger for slower decay, exponential
 	defaultCopyTimeoutDuration = fs.Duration(time.Minute)
+	defaultCopyPollInterval    = fs.Duration(10 * time.Second)
+	defaultRequestTimeout      = fs.Duration(5 * time.Minute)
+	memoryPoolFlushTime        = fs.Duration(time.Minute) // flush the cached buffers after this long
+	memoryPoolUseMmap          = false
+)
+
+// values of the upload_condition option
+const (
+	uploadConditionCreateOnly  = "create-only"
+	uploadConditionIfUnchanged = "if-unchanged"
 )
 
 const (
@@ -40,30 +61,63 @@ https://docs.oracle.com/en-us/iaas/Content/Identity/Tasks/callingservicesfromins
 
 	resourcePrincipalHelpText = `use resource principals to make API calls`
 
-	environmentAuthHelpText = `automatically pickup the credentials from runtime(env), first one to provide auth wins`
+	environmentAuthHelpText = `automatically pickup the credentials from runtime(env), first one to provide auth wins.
+blank namespace, compartment, region and endpoint options are read from OCI_* environment variables`
 
 	noAuthHelpText = `no credentials needed, this is typically for reading public buckets`
 )
 
 // Options defines the configuration for this backend
 type Options struct {
-	Provider          string               `config:"provider"`
-	Compartment       string               `config:"compartment"`
-	Namespace         string               `config:"namespace"`
-	Region            string               `config:"region"`
-	Endpoint          string               `config:"endpoint"`
-	Enc               encoder.MultiEncoder `config:"encoding"`
-	ConfigFile        string               `config:"config_file"`
-	ConfigProfile     string               `config:"config_profile"`
-	UploadCutoff      fs.SizeSuffix        `config:"upload_cutoff"`
-	ChunkSize         fs.SizeSuffix        `config:"chunk_size"`
-	UploadConcurrency int                  `config:"upload_concurrency"`
-	DisableChecksum   bool                 `config:"disable_checksum"`
-	CopyCutoff        fs.SizeSuffix        `config:"copy_cutoff"`
-	CopyTimeout       fs.Duration          `config:"copy_timeout"`
-	StorageTier       string               `config:"storage_tier"`
-	LeavePartsOnError bool                 `config:"leave_parts_on_error"`
-	NoCheckBucket     bool                 `config:"no_check_bucket"`
+	Provider                string               `config:"provider"`
+	Compartment             string               `config:"compartment"`
+	Namespace               string               `config:"namespace"`
+	Region                  string               `config:"region"`
+	Endpoint                string               `config:"endpoint"`
+	RealmSpecificEndpoint   bool                 `config:"realm_specific_endpoint"`
+	Enc                     encoder.MultiEncoder `config:"encoding"`
+	ConfigFile              string               `config:"config_file"`
+	ConfigProfile           string               `config:"config_profile"`
+	UploadCutoff            fs.SizeSuffix        `config:"upload_cutoff"`
+	ForceMultipart          bool                 `config:"force_multipart"`
+	ChunkSize               fs.SizeSuffix        `config:"chunk_size"`
+	UploadConcurrency       uploadConcurrency    `config:"upload_concurrency"`
+	MaxUploadParts          int                  `config:"max_upload_parts"`
+	DownloadConcurrency     int                  `config:"download_concurrency"`
+	MightGzip               bool                 `config:"might_gzip"`
+	DisableChecksum         bool                 `config:"disable_checksum"`
+	DisableCrc32c           bool                 `config:"disable_crc32c"`
+	CopyCutoff              fs.SizeSuffix        `config:"copy_cutoff"`
+	DisableServerSideCopy   bool                 `config:"disable_server_side_copy"`
+	CopyConcurrency         int                  `config:"copy_concurrency"`
+	CopyTimeout             fs.Duration          `config:"copy_timeout"`
+	CopyPollInterval        fs.Duration          `config:"copy_poll_interval"`
+	RequestTimeout          fs.Duration          `config:"request_timeout"`
+	MaxRetries              int                  `config:"max_retries"`
+	ClientRequestIDPrefix   string               `config:"client_request_id_prefix"`
+	RunID                   string               `config:"run_id"`
+	ServerSideAcrossConfigs bool                 `config:"server_side_across_configs"`
+	StorageTier             string               `config:"storage_tier"`
+	PreserveTier            bool                 `config:"preserve_tier"`
+	WarnRetrievalCost       bool                 `config:"warn_retrieval_cost"`
+	SSEKMSKeyID             string               `config:"sse_kms_key_id"`
+	SSECustomerKeyFile      string               `config:"sse_customer_key_file"`
+	LeavePartsOnError       bool                 `config:"leave_parts_on_error"`
+	AttemptResumeOnMismatch bool                 `config:"attempt_resume_on_checksum_mismatch"`
+	BucketAutoTiering       string               `config:"bucket_auto_tiering"`
+	ListChunk               int                  `config:"list_chunk"`
+	ListFields              string               `config:"list_fields"`
+	RootPrefix              string               `config:"root_prefix"`
+	NoCheckBucket           bool                 `config:"no_check_bucket"`
+	NoHead                  bool                 `config:"no_head"`
+	NoHeadObject            bool                 `config:"no_head_object"`
+	ListCacheTTL            fs.Duration          `config:"list_cache_ttl"`
+	MemoryPoolFlushTime     fs.Duration          `config:"memory_pool_flush_time"`
+	MemoryPoolUseMmap       bool                 `config:"memory_pool_use_mmap"`
+	DisableHTTP2            bool                 `config:"disable_http2"`
+	UploadCondition         string               `config:"upload_condition"`
+	CheckReplication        bool                 `config:"check_replication"`
+	ChunkTimeout            fs.Duration          `config:"chunk_timeout"`
 }
 
 func newOptions() []fs.Option {
@@ -102,9 +156,36 @@ func newOptions() []fs.Option {
 		Help:     "Object storage Region",
 		Required: true,
 	}, {
-		Name:     "endpoint",
-		Help:     "Endpoint for Object storage API.\n\nLeave blank to use the default endpoint for the region.",
+		Name: "endpoint",
+		Help: `Endpoint for Object storage API.
+
+Leave blank to use the default endpoint for the region. This is in
+the realm of the region, so for example us-gov-ashburn-1 uses
+oraclegovcloud.com and uk-gov-london-1 uses oraclegovcloud.uk. The
+region may also be given as its short code such as iad.
+
+Set this to use a private endpoint or a dedicated endpoint for the
+namespace, for example
+
+    https://<namespace>.objectstorage.<region>.oci.customer-oci.com
+
+The scheme may be left off in which case https is used. All requests,
+including multipart uploads and server-side copies, are sent here.`,
 		Required: false,
+	}, {
+		Name: "realm_specific_endpoint",
+		Help: `Use the dedicated endpoint of the namespace for the region.
+
+If set, and no endpoint is given, requests are sent to the realm
+specific endpoint of the namespace
+
+    https://<namespace>.objectstorage.<region>.oci.customer-oci.com
+
+instead of the default endpoint of the region. This is also set by
+setting the OCI_REALM_SPECIFIC_SERVICE_ENDPOINT_TEMPLATE_ENABLED
+environment variable to true, as for the OCI SDKs and CLI.`,
+		Default:  false,
+		Advanced: true,
 	}, {
 		Name:     "config_file",
 		Help:     "Path to OCI config file",
@@ -125,8 +206,11 @@ func newOptions() []fs.Option {
 		}},
 	}, {
 		// Mapping from here: https://github.com/oracle/oci-go-sdk/blob/master/objectstorage/storage_tier.go
-		Name:     "storage_tier",
-		Help:     "The storage class to use when storing new objects in storage. https://docs.oracle.com/en-us/iaas/Content/Object/Concepts/understandingstoragetiers.htm",
+		Name: "storage_tier",
+		Help: `The storage class to use when storing new objects in storage. https://docs.oracle.com/en-us/iaas/Content/Object/Concepts/understandingstoragetiers.htm
+
+When --metadata is in use this can be overridden for each object with
+a "tier" metadata key, e.g. --metadata --metadata-set tier=Archive`,
 		Default:  "Standard",
 		Advanced: true,
 		Examples: []fs.OptionExample{{
@@ -139,14 +223,71 @@ func newOptions() []fs.Option {
 			Value: "Archive",
 			Help:  "Archive storage tier",
 		}},
+	}, {
+		Name: "preserve_tier",
+		Help: `Keep the storage tier of objects copied server-side.
+
+Normally server-side copies are put in the default storage tier of the
+destination bucket. Setting this puts each copy in the tier of the
+object it was copied from instead, so reorganising an archive bucket
+doesn't move its objects to the Standard tier.
+
+Objects moved within a bucket are renamed so always keep their tier.`,
+		Default:  false,
+		Advanced: true,
+	}, {
+		Name: "warn_retrieval_cost",
+		Help: `Warn when reading objects which cost extra to retrieve.
+
+Reading objects in the InfrequentAccess tier, or restored objects in
+the Archive tier, incurs a retrieval fee per GB. If this is set rclone
+logs a warning the first time it reads such an object, for example in
+a sync which reads its destination, so the charges aren't a surprise.`,
+		Default:  false,
+		Advanced: true,
+	}, {
+		Name: "sse_kms_key_id",
+		Help: `OCID of a master encryption key in the Vault service to encrypt new objects with.
+
+If not set objects are encrypted with the bucket's default key, which
+is an Oracle managed key unless the bucket has been given one.
+
+If this is the default key of the bucket at the root of the remote
+then it isn't sent with uploads, leaving the bucket's default to
+apply, as some buckets with an enforced key reject requests which set
+one.`,
+		Advanced: true,
+	}, {
+		Name: "sse_customer_key_file",
+		Help: `Path to a file holding a 256-bit key to encrypt objects with SSE-C.
+
+The file must contain exactly 32 bytes, the raw key. Rclone sends it
+base64 encoded with its SHA256 with every object request, so objects
+are encrypted with it when uploaded and decrypted with it when read.
+
+Object storage doesn't keep the key, so objects can't be read without
+it. This can't be used with sse_kms_key_id.`,
+		Advanced: true,
 	}, {
 		Name: "upload_cutoff",
 		Help: `Cutoff for switching to chunked upload.
 
 Any files larger than this will be uploaded in chunks of chunk_size.
-The minimum is 0 and the maximum is 5 GiB.`,
+The minimum is 0 and the maximum is 5 GiB.
+
+Setting this to 0 uploads all files as multipart uploads, except empty
+files which are always uploaded in a single part.`,
 		Default:  defaultUploadCutoff,
 		Advanced: true,
+	}, {
+		Name: "force_multipart",
+		Help: `If set, upload all files as multipart uploads whatever upload_cutoff is.
+
+This uploads even small files in chunks of chunk_size, uploading
+upload_concurrency chunks at once. Empty files are still uploaded in a
+single part as there is no data to put in a part.`,
+		Default:  false,
+		Advanced: true,
 	}, {
 		Name: "chunk_size",
 		Help: `Chunk size to use for uploading.
@@ -165,14 +306,19 @@ enough memory, then increasing this will speed up the transfers.
 Rclone will automatically increase the chunk size when uploading a
 large file of known size to stay below the 10,000 chunks limit.
 
-Files of unknown size are uploaded with the configured
-chunk_size. Since the default chunk size is 5 MiB and there can be at
-most 10,000 chunks, this means that by default the maximum size of
-a file you can stream upload is 48 GiB.  If you wish to stream upload
-larger files then you will need to increase chunk_size.
+Files of unknown size which fit in one chunk are uploaded in a single
+part. Larger ones are uploaded with the configured chunk_size for the
+first 1,000 chunks, doubling the chunk size every 1,000 chunks after
+that so that they stay below the 10,000 chunks limit. Files of unknown
+size beyond 48 GiB will therefore use more memory per transfer. If a
+stream reaches 80% of the chunks limit a notice is logged with the
+largest stream which can be uploaded with this chunk size.
 
 Increasing the chunk size decreases the accuracy of the progress
 statistics displayed with "-P" flag.
+
+The minimum is 5 MiB, the smallest part object storage accepts other
+than the last one, and the maximum is 50 GiB.
 `,
 		Default:  minChunkSize,
 		Advanced: true,
@@ -185,19 +331,101 @@ concurrently.
 
 If you are uploading small numbers of large files over high-speed links
 and these uploads do not fully utilize your bandwidth, then increasing
-this may help to speed up the transfers.`,
-		Default:  defaultUploadConcurrency,
+this may help to speed up the transfers.
+
+Set this to "auto" to scale the concurrency with the size of the file,
+uploading one more chunk at once for every 64 MiB up to 20 chunks.
+This uses less memory for files just over upload_cutoff and more
+concurrency for very large files. Files of unknown size are uploaded
+with a concurrency of 10.`,
+		Default:  uploadConcurrency(defaultUploadConcurrency),
+		Advanced: true,
+	}, {
+		Name: "max_upload_parts",
+		Help: `Maximum number of parts in a multipart upload.
+
+This option defines the maximum number of multipart chunks to use
+when doing a multipart upload. OCI allows at most 10,000.
+
+Rclone will automatically increase the chunk size when uploading a
+large file of a known size to stay below this number of chunks limit.
+If that would make upload_concurrency chunks use more memory than
+upload_concurrency * chunk_size then fewer chunks are uploaded at once.
+`,
+		Default:  maxUploadParts,
+		Advanced: true,
+	}, {
+		Name: "download_concurrency",
+		Help: `Concurrency for downloads.
+
+If this is greater than 1 then objects bigger than chunk_size are
+downloaded with this many concurrent ranged requests of chunk_size
+each. Note that "download_concurrency" chunks of this size are
+buffered in memory per transfer.
+
+Objects stored with "Content-Encoding: gzip" are always downloaded
+with a single request.
+
+This applies when the whole object is read, for example when copying
+to another backend. Rclone's --multi-thread-streams already downloads
+to local disk with ranged requests regardless of this setting.`,
+		Default:  1,
+		Advanced: true,
+	}, {
+		Name: "might_gzip",
+		Help: `Set this if something in front of object storage might gzip objects.
+
+Object storage doesn't alter objects when they are downloaded, but a
+proxy or gateway in front of it may gzip them on the fly, or
+decompress objects uploaded with "Content-Encoding: gzip".
+
+A symptom of this would be receiving errors like
+
+    ERROR corrupted on transfer: sizes differ NNN vs MMM
+
+If you set this flag and rclone downloads a whole object with chunked
+transfer encoding then it doesn't check the size and hash of what it
+reads, and if it is sent with "Content-Encoding: gzip" rclone will
+decompress it on the fly.`,
+		Default:  false,
 		Advanced: true,
 	}, {
 		Name: "copy_cutoff",
 		Help: `Cutoff for switching to multipart copy.
 
-Any files larger than this that need to be server-side copied will be
-copied in chunks of this size.
+Note that object storage has no API to copy part of an object, so
+server-side copies of any size are done by a single asynchronous work
+request which the service runs itself. This is not currently used to
+split copies into chunks.
 
-The minimum is 0 and the maximum is 5 GiB.`,
+If the service rejects a server-side copy the object is downloaded and
+uploaded again instead.`,
 		Default:  fs.SizeSuffix(maxSizeForCopy),
 		Advanced: true,
+	}, {
+		Name: "disable_server_side_copy",
+		Help: `If set, don't use server-side copies.
+
+Objects are downloaded and uploaded again instead of being copied by a
+work request, and have their modification time updated that way too.
+
+This is needed in tenancies where the object storage service isn't
+allowed to run copy work requests, so copies don't have to fail before
+falling back.`,
+		Default:  false,
+		Advanced: true,
+	}, {
+		Name: "copy_concurrency",
+		Help: `Concurrency for server-side copies.
+
+This is the most server-side copy work requests which are run at once,
+independent of upload_concurrency. Objects moved within a bucket are
+renamed this many at once too.
+
+Copies are started --transfers at a time so this has no effect if it
+is bigger than --transfers.`,
+		Default:  defaultCopyConcurrency,
+		Advanced: true,
 	}, {
 		Name: "copy_timeout",
 		Help: `Timeout for copy.
@@ -206,6 +434,81 @@ Copy is an asynchronous operation, specify timeout to wait for copy to succeed
 `,
 		Default:  defaultCopyTimeoutDuration,
 		Advanced: true,
+	}, {
+		Name: "copy_poll_interval",
+		Help: `Maximum interval between checks of a copy's progress.
+
+Rclone checks the status of an asynchronous copy 100ms after starting
+it and doubles the interval between checks each time up to this
+maximum. The progress of the copy is logged at INFO level.
+`,
+		Default:  defaultCopyPollInterval,
+		Advanced: true,
+	}, {
+		Name: "request_timeout",
+		Help: `Timeout for each request to object storage.
+
+Each attempt at a request is cancelled if it hasn't completed within
+this time, so a hung connection is retried instead of stalling the
+transfer. The deadline starts again on each retry.
+
+This doesn't apply to requests which transfer object data, uploads and
+downloads, which are covered by the global --timeout instead.
+
+Set to 0 to disable.`,
+		Default:  defaultRequestTimeout,
+		Advanced: true,
+	}, {
+		Name: "max_retries",
+		Help: `Maximum number of attempts at each request to object storage.
+
+Requests which fail with errors which can be retried, such as rate
+limiting or server errors, are tried this many times with increasing
+sleeps in between before the error is returned. Set this low to fail
+fast, for example in CI.
+
+If set the OCI SDK doesn't retry requests itself, so this is the total
+number of attempts. Set to 0 to use --low-level-retries with the SDK's
+own retries underneath, which can take minutes to give up.`,
+		Default:  0,
+		Advanced: true,
+	}, {
+		Name: "client_request_id_prefix",
+		Help: `Prefix for the opc-client-request-id of each request.
+
+If set, every request is sent with an opc-client-request-id of this
+prefix followed by a dash and a random UUID, so the requests made by
+this remote can be found in the OCI audit logs. It is shown with the
+opc-request-id in errors.
+
+If not set the SDK's random request IDs are used.`,
+		Advanced: true,
+	}, {
+		Name: "run_id",
+		Help: `ID of the job or pipeline run to stamp uploads with.
+
+If set, every object uploaded is given this in its
+opc-meta-rclone-run-id metadata, so it can be traced back to the run
+which wrote it. It is shown in the metadata of the object as
+rclone-run-id.
+
+In pipelines this can be set with the RCLONE_OOS_RUN_ID environment
+variable.`,
+		Advanced: true,
+	}, {
+		Name: "server_side_across_configs",
+		Help: `Allow server-side copies to work across different configs.
+
+This allows objects to be copied between remotes in different regions
+or namespaces. The copy is requested from the source region so the
+object storage service there needs the policy allowing it to copy
+objects into the destination bucket.
+
+Copies between remotes in the same namespace and region are always
+server-side, whatever their auth providers, as they are requested with
+the credentials of the destination.`,
+		Default:  false,
+		Advanced: true,
 	}, {
 		Name: "disable_checksum",
 		Help: `Don't store MD5 checksum with object metadata.
@@ -213,7 +516,25 @@ Copy is an asynchronous operation, specify timeout to wait for copy to succeed
 Normally rclone will calculate the MD5 checksum of the input before
 uploading it so it can add it to metadata on the object. This is great
 for data integrity checking but can cause long delays for large files
-to start uploading.`,
+to start uploading.
+
+This also stops rclone checking the MD5 checksums of the parts and of
+the whole object of multipart uploads. When checking, a multipart upload
+which doesn't match is deleted unless leave_parts_on_error is set.
+
+This doesn't affect downloads, which the backend doesn't check itself.
+Instead rclone compares the MD5 of the data downloaded with that of the
+object after copying it, which can be skipped with --ignore-checksum.`,
+		Default:  false,
+		Advanced: true,
+	}, {
+		Name: "disable_crc32c",
+		Help: `Don't send or read CRC32C checksums.
+
+Normally rclone sends the CRC32C checksum of each part of a multipart
+upload, and of single part uploads when the source can supply it, so
+object storage can verify the data it receives. The CRC32C of single
+part objects is also available as a hash.`,
 		Default:  false,
 		Advanced: true,
 	}, {
@@ -236,12 +557,85 @@ to start uploading.`,
 		Help: `If true avoid calling abort upload on a failure, leaving all successfully uploaded parts on S3 for manual recovery.
 
 It should be set to true for resuming uploads across different sessions.
+To keep the parts of only some uploads set it for those runs alone with
+--oos-leave-parts-on-error rather than in the config.
 
 WARNING: Storing parts of an incomplete multipart upload counts towards space usage on object storage and will add
 additional costs if not cleaned up.
 `,
 		Default:  false,
 		Advanced: true,
+	}, {
+		Name: "attempt_resume_on_checksum_mismatch",
+		Help: `If set, upload again once if a multipart upload fails verification.
+
+After a multipart upload rclone checks the MD5 of the object object
+storage assembled against the data it read. Normally a mismatch is an
+error, but it can be a transient problem on the way rather than
+corruption of the source. If this is set rclone deletes the object and
+uploads it again once before giving up.
+
+The upload can only be retried if the source is an object rclone can
+open again, for example a local file or an object on another remote,
+otherwise, as for streams from rclone rcat, the error is returned as
+usual.`,
+		Default:  false,
+		Advanced: true,
+	}, {
+		Name: "bucket_auto_tiering",
+		Help: `Auto-tiering to set on buckets rclone creates.
+
+With auto-tiering objects which aren't accessed are moved to the
+InfrequentAccess tier to reduce storage costs. This only affects
+buckets created by rclone. Use the "auto-tiering" backend command to
+read the auto-tiering of an existing bucket.`,
+		Default:  string(objectstorage.BucketAutoTieringDisabled),
+		Advanced: true,
+		Examples: []fs.OptionExample{{
+			Value: string(objectstorage.BucketAutoTieringDisabled),
+			Help:  "Auto-tiering disabled",
+		}, {
+			Value: string(objectstorage.BucketAutoTieringInfrequentaccess),
+			Help:  "Move objects which aren't accessed to the InfrequentAccess tier",
+		}},
+	}, {
+		Name: "list_chunk",
+		Help: `Size of listing chunk (number of objects returned by each ListObjects request).
+
+Smaller chunks return the first results of a listing sooner, larger
+chunks need fewer requests to list big buckets. The maximum is 1000.`,
+		Default:  maxListChunk,
+		Advanced: true,
+	}, {
+		Name: "list_fields",
+		Help: `Comma separated fields to read for each object in a listing.
+
+By default listings return everything rclone uses about an object so
+syncing doesn't need a HEAD request for each one. The fields can be
+trimmed to make listings smaller, in which case rclone reads what is
+missing with a HEAD when it is needed: the md5 for --checksum, the
+timeModified for --use-server-modtime and the storageTier for the
+tier. The etag is only read from listings.
+
+name and size are always read. The possible fields are
+` + "`" + `name,size,etag,timeCreated,md5,timeModified,storageTier,archivalState` + "`" + `.`,
+		Default:  listFields,
+		Advanced: true,
+	}, {
+		Name: "root_prefix",
+		Help: `Prefix to add to the names of all the objects in a bucket.
+
+The prefix is added to every object name rclone reads or writes and
+removed from the names it lists, so the objects under it look like the
+whole bucket. For example with root_prefix set to "job1" the remote
+"oos:bucket/dir/file.txt" is the object "job1/dir/file.txt".
+
+This lets several rclone jobs share one bucket under different
+prefixes without each of them being given the prefix in its paths.
+Removing the root of the remote, eg with rclone purge, only removes
+the objects under the prefix and not the bucket. Slashes at the start
+and end of the prefix are ignored.`,
+		Advanced: true,
 	}, {
 		Name: "no_check_bucket",
 		Help: `If set, don't attempt to check the bucket exists or create it.
@@ -250,9 +644,266 @@ This can be useful when trying to minimise the number of transactions
 rclone does if you know the bucket exists already.
 
 It can also be needed if the user you are using does not have bucket
-creation permissions.
+creation permissions. No bucket metadata is read either, so it can be
+used with credentials which lack BUCKET_INSPECT.
+
+It also skips checking that an endpoint which names its region, such
+as https://objectstorage.us-ashburn-1.oraclecloud.com, is in the
+region set with region.
 `,
 		Default:  false,
 		Advanced: true,
+	}, {
+		Name: "no_head",
+		Help: `If set, don't HEAD uploaded objects to check integrity.
+
+This can be useful when trying to minimise the number of transactions
+rclone does.
+
+Setting it means that if rclone receives a 200 OK message after
+uploading an object with PUT then it will assume that it got uploaded
+properly.
+
+In particular it will assume:
+
+- the metadata, including modtime, storage tier and content type was as uploaded
+- the size was as uploaded
+
+It reads the following items from the response for a single part PUT:
+
+- the MD5SUM
+- The uploaded date
+
+For a multipart upload it reads the uploaded date from the response
+to the commit and uses the MD5SUM rclone calculated while uploading,
+so no HEAD request is done either.
+
+Setting this flag increases the chance for undetected upload failures,
+in particular an incorrect size, so it isn't recommended for normal
+operation. In practice the chance of an undetected upload failure is
+very small even with this flag.
+`,
+		Default:  false,
+		Advanced: true,
+	}, {
+		Name: "no_head_object",
+		Help: `If set, find single objects with a listing rather than a HEAD.
+
+Normally rclone does a HEAD request to read the size, hash, modification
+time and storage tier of an object it is asked about by name.
+
+Setting this makes rclone list the object's name with a limit of one
+item instead, which returns the same information in one call and can
+avoid HEAD permission quirks. If the listing doesn't find the object
+rclone falls back to doing a HEAD.
+
+Note that the object metadata, including the modtime stored by rclone,
+isn't returned by a listing so it may still be read with a HEAD later.
+`,
+		Default:  false,
+		Advanced: true,
+	}, {
+		Name: "list_cache_ttl",
+		Help: `How long to remember the objects seen in recursive listings.
+
+If set, the objects returned by a recursive listing, for example by
+--fast-list, are remembered for this long and rclone finds objects it
+is asked about by name in this cache rather than with a HEAD request
+each. This speeds up syncs which look up many destination objects,
+such as with --files-from or --no-traverse after a listing.
+
+Objects changed by this rclone are forgotten straight away, but those
+changed by anything else may be seen as they were listed until the
+cache entry expires. As with no_head_object the object metadata,
+including the modtime stored by rclone, isn't in the listing so it may
+still be read with a HEAD later.
+
+The default of 0 disables the cache.
+`,
+		Default:  fs.Duration(0),
+		Advanced: true,
+	}, {
+		Name:     "memory_pool_flush_time",
+		Default:  memoryPoolFlushTime,
+		Advanced: true,
+		Help: `How often internal memory buffer pools will be flushed.
+
+Uploads which requires additional buffers (f.e multipart) will use memory pool for allocations.
+This option controls how often unused buffers will be removed from the pool.`,
+	}, {
+		Name:     "memory_pool_use_mmap",
+		Default:  memoryPoolUseMmap,
+		Advanced: true,
+		Help:     `Whether to use mmap buffers in internal memory pool.`,
+	}, {
+		Name: "disable_http2",
+		Help: `Disable usage of HTTP/2 for object storage requests.
+
+Some proxies and load balancers in front of object storage give
+HTTP/2 stream errors. Set this to make the requests with HTTP/1.1
+only. The global --disable-http2 flag already does this for every
+backend, use this to only change it for this one. Nothing else about
+the http client is changed.`,
+		Default:  false,
+		Advanced: true,
+	}, {
+		Name: "upload_condition",
+		Help: `Only upload if the object is as expected, for safe concurrent writers.
+
+With create-only every upload is sent with If-None-Match: * so it fails
+rather than replace an object which exists already.
+
+With if-unchanged uploads of new objects are sent with If-None-Match: *
+and uploads replacing an object with If-Match and the ETag the object
+had when rclone read it, so they fail if another writer has changed it
+since. Leave etag in list_fields so the ETag is read by listings.
+
+Multipart uploads are checked when they are started and committed. An
+upload which fails the condition isn't retried and gives a
+"precondition failed" error.`,
+		Advanced: true,
+		Examples: []fs.OptionExample{{
+			Value: "",
+			Help:  "Upload whether or not the object exists",
+		}, {
+			Value: uploadConditionCreateOnly,
+			Help:  "Never replace an existing object",
+		}, {
+			Value: uploadConditionIfUnchanged,
+			Help:  "Only replace an object if it hasn't changed since it was read",
+		}},
+	}, {
+		Name: "check_replication",
+		Help: `Warn if the bucket is part of a replication policy.
+
+If set rclone reads the replication status of the bucket in the remote
+when the backend starts and logs a warning if the bucket is the source
+of a replication policy, as objects deleted from it, for example by
+rclone sync, are deleted from its replicas too. It also warns if the
+bucket is the read only destination of a replication policy.
+
+This costs one extra request each time the backend starts so it is off
+by default. It is a guardrail for disaster recovery sources, use
+--max-delete or --dry-run as well to limit what can be deleted.`,
+		Default:  false,
+		Advanced: true,
+	}, {
+		Name: "chunk_timeout",
+		Help: `Timeout for uploading each part of a multipart upload.
+
+Each attempt at uploading a part is cancelled if it hasn't completed
+within this time and that part is retried, while the other parts carry
+on, so a single stuck part doesn't stall the whole upload. The deadline
+starts again on each retry.
+
+Set it comfortably above the time a part of chunk_size takes to upload
+on your connection. Set to 0 to disable.`,
+		Default:  fs.Duration(0),
+		Advanced: true,
 	}}
 }
+
+// environment variables read by env_auth for options which are left
+// blank, in order of precedence
+var optionEnvVars = []struct {
+	name string
+	vars []string
+	get  func(*Options) *string
+}{
+	{"namespace", []string{"OCI_NAMESPACE"}, func(opt *Options) *string { return &opt.Namespace }},
+	{"compartment", []string{"OCI_COMPARTMENT", "OCI_COMPARTMENT_ID"}, func(opt *Options) *string { return &opt.Compartment }},
+	{"region", []string{"OCI_REGION", "OCI_RESOURCE_PRINCIPAL_REGION"}, func(opt *Options) *string { return &opt.Region }},
+	{"endpoint", []string{"OCI_ENDPOINT"}, func(opt *Options) *string { return &opt.Endpoint }},
+}
+
+// setOptionsFromEnv fills in the blank options of an env_auth remote
+// from environment variables so it can be used without a config file
+func setOptionsFromEnv(opt *Options) {
+	if opt.Provider != environmentAuth {
+		return
+	}
+	for _, option := range optionEnvVars {
+		value := option.get(opt)
+		if *value != "" {
+			continue
+		}
+		for _, envVar := range option.vars {
+			if *value = os.Getenv(envVar); *value != "" {
+				fs.Debugf(nil, "oos: using %s from $%s", option.name, envVar)
+				break
+			}
+		}
+	}
+}
+
+// uploadConcurrency is the upload_concurrency option, which is a
+// number of parts or auto to scale it with the size of the upload
+type uploadConcurrency int
+
+// autoUploadConcurrency is the value of upload_concurrency auto
+const autoUploadConcurrency uploadConcurrency = -1
+
+// String renders the concurrency as a number or auto
+func (c uploadConcurrency) String() string {
+	if c == autoUploadConcurrency {
+		return "auto"
+	}
+	return strconv.Itoa(int(c))
+}
+
+// Set the concurrency from a number or auto
+func (c *uploadConcurrency) Set(s string) error {
+	if strings.EqualFold(s, "auto") {
+		*c = autoUploadConcurrency
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("upload_concurrency must be a number or auto, got %q", s)
+	}
+	*c = uploadConcurrency(n)
+	return nil
+}
+
+// Type of the value
+func (uploadConcurrency) Type() string {
+	return "int|auto"
+}
+
+// Scan implements the fmt.Scanner interface
+func (c *uploadConcurrency) Scan(s fmt.ScanState, ch rune) error {
+	token, err := s.Token(true, nil)
+	if err != nil {
+		return err
+	}
+	return c.Set(string(token))
+}
+
+// forSize returns the number of parts to upload at once for an upload
+// of size bytes, which is -1 if it isn't known
+func (c uploadConcurrency) forSize(size int64) int {
+	if c != autoUploadConcurrency {
+		if c < 1 {
+			return 1
+		}
+		return int(c)
+	}
+	if size < 0 {
+		return defaultUploadConcurrency
+	}
+	n := int((size + int64(autoUploadConcurrencySize) - 1) / int64(autoUploadConcurrencySize))
+	if n < 1 {
+		n = 1
+	} else if n > maxAutoUploadConcurrency {
+		n = maxAutoUploadConcurrency
+	}
+	return n
+}
+
+// max returns the most parts uploaded at once by one upload
+func (c uploadConcurrency) max() int {
+	if c == autoUploadConcurrency {
+		return maxAutoUploadConcurrency
+	}
+	return c.forSize(-1)
+}
@@ -6,11 +6,17 @@ package oracleobjectstorage
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"path"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/oracle/oci-go-sdk/v65/common"
@@ -23,8 +29,60 @@ import (
 	"github.com/rclone/rclone/fs/walk"
 	"github.com/rclone/rclone/lib/bucket"
 	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/pool"
 )
 
+// systemMetadataInfo describes the metadata which isn't stored as
+// opc-meta- keys
+var systemMetadataInfo = map[string]fs.MetadataHelp{
+	"cache-control": {
+		Help:    "Cache-Control header",
+		Type:    "string",
+		Example: "no-cache",
+	},
+	"content-disposition": {
+		Help:    "Content-Disposition header",
+		Type:    "string",
+		Example: "inline",
+	},
+	"content-encoding": {
+		Help:    "Content-Encoding header",
+		Type:    "string",
+		Example: "gzip",
+	},
+	"content-language": {
+		Help:    "Content-Language header",
+		Type:    "string",
+		Example: "en-US",
+	},
+	"content-type": {
+		Help:    "Content-Type header",
+		Type:    "string",
+		Example: "text/plain",
+	},
+	"tier": {
+		Help:    "Storage tier of the object",
+		Type:    "string",
+		Example: "InfrequentAccess",
+	},
+	"mtime": {
+		Help:    "Time of last modification, read from rclone metadata",
+		Type:    "RFC 3339",
+		Example: "2006-01-02T15:04:05.999999999Z07:00",
+	},
+	"btime": {
+		Help:    "Time of file birth (creation), read from rclone metadata",
+		Type:    "RFC 3339",
+		Example: "2006-01-02T15:04:05.999999999Z07:00",
+	},
+	metaRetentionUntil: {
+		Help:     "Time until which the retention rules of the bucket keep the object, or indefinite",
+		Type:     "RFC 3339 or indefinite",
+		Example:  "2006-01-02T15:04:05Z07:00",
+		ReadOnly: true,
+	},
+}
+
 // Register with Fs
 func init() {
 	fs.Register(&fs.RegInfo{
@@ -33,7 +91,11 @@ func init() {
 		Prefix:      "oos",
 		NewFs:       NewFs,
 		CommandHelp: commandHelp,
-		Options:     newOptions(),
+		MetadataInfo: &fs.MetadataInfo{
+			System: systemMetadataInfo,
+			Help:   `User metadata is stored as opc-meta- keys. Object storage metadata keys are case insensitive and are always returned in lower case.`,
+		},
+		Options: newOptions(),
 	})
 }
 
@@ -49,6 +111,16 @@ type Fs struct {
 	rootDirectory string                             // directory part of root (if any)
 	cache         *bucket.Cache                      // cache for bucket creation status
 	pacer         *fs.Pacer                          // To pace the API calls
+	pool          *pool.Pool                         // memory pool
+	uploadsMu     sync.Mutex                         // protects uploads
+	uploads       map[string]activeUpload            // multipart uploads in progress by upload ID
+	listFields    map[string]bool                    // fields requested for objects in listings
+	bucketKMSKey  bool                               // sse_kms_key_id is the default key of the root bucket
+	warnRetrieval sync.Once                          // warn once about retrieval costs
+	sseKey        *sseCustomerKey                    // SSE-C key from sse_customer_key_file if set
+	copyTokens    *pacer.TokenDispenser              // limits server-side copies to copy_concurrency
+	listCache     *listCache                         // objects seen in recursive listings if list_cache_ttl is set
+	retention     retentionCache                     // retention rules by bucket, read when needed
 }
 
 // NewFs Initialize backend
@@ -59,30 +131,99 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if err != nil {
 		return nil, err
 	}
+	setOptionsFromEnv(opt)
+	if opt.ListChunk < 1 || opt.ListChunk > maxListChunk {
+		return nil, fmt.Errorf("list_chunk %d must be between 1 and %d", opt.ListChunk, maxListChunk)
+	}
+	err = checkUploadChunkSize(opt.ChunkSize)
+	if err != nil {
+		return nil, fmt.Errorf("chunk_size: %w", err)
+	}
+	if opt.MaxRetries < 0 {
+		return nil, fmt.Errorf("max_retries %d must not be negative", opt.MaxRetries)
+	}
+	if opt.CopyConcurrency < 1 {
+		return nil, fmt.Errorf("copy_concurrency %d must be at least 1", opt.CopyConcurrency)
+	}
+	if _, ok := objectstorage.GetMappingBucketAutoTieringEnum(opt.BucketAutoTiering); !ok {
+		return nil, fmt.Errorf("not a valid bucket auto tiering: %v", opt.BucketAutoTiering)
+	}
+	opt.RootPrefix = parsePath(opt.RootPrefix)
+	switch opt.UploadCondition {
+	case "", uploadConditionCreateOnly, uploadConditionIfUnchanged:
+	default:
+		return nil, fmt.Errorf("upload_condition %q must be %q or %q", opt.UploadCondition, uploadConditionCreateOnly, uploadConditionIfUnchanged)
+	}
+	listFields, err := parseListFields(opt.ListFields)
+	if err != nil {
+		return nil, err
+	}
+	err = checkNamespace(opt.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Provider != noAuth {
+		err = checkCompartment(opt.Compartment)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opt.Endpoint != "" && !opt.NoCheckBucket {
+		err = checkEndpointRegion(getEndpoint(opt), opt.Region)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var sseKey *sseCustomerKey
+	if opt.SSECustomerKeyFile != "" {
+		if opt.SSEKMSKeyID != "" {
+			return nil, errors.New("sse_kms_key_id and sse_customer_key_file can't both be set")
+		}
+		sseKey, err = readSSECustomerKeyFile(opt.SSECustomerKeyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
 	ci := fs.GetConfig(ctx)
 	objectStorageClient, err := newObjectStorageClient(ctx, opt)
 	if err != nil {
 		return nil, err
 	}
-	p := pacer.NewDefault(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))
+	p := newJitterCalculator(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))
 	f := &Fs{
-		name:  name,
-		opt:   *opt,
-		ci:    ci,
-		srv:   objectStorageClient,
-		cache: bucket.NewCache(),
-		pacer: fs.NewPacer(ctx, p),
+		name:       name,
+		opt:        *opt,
+		ci:         ci,
+		srv:        objectStorageClient,
+		cache:      bucket.NewCache(),
+		pacer:      fs.NewPacer(ctx, p),
+		uploads:    map[string]activeUpload{},
+		listFields: listFields,
+		copyTokens: pacer.NewTokenDispenser(opt.CopyConcurrency),
+		sseKey:     sseKey,
+		listCache:  newListCache(time.Duration(opt.ListCacheTTL)),
+	}
+	if opt.MaxRetries > 0 {
+		f.pacer.SetRetries(opt.MaxRetries)
 	}
+	f.pool = f.newMemoryPool(int64(opt.ChunkSize))
+	f.checkUploadMemory()
 	f.setRoot(root)
 	f.features = (&fs.Features{
-		ReadMimeType:      true,
-		WriteMimeType:     true,
-		BucketBased:       true,
-		BucketBasedRootOK: true,
-		SetTier:           true,
-		GetTier:           true,
-		SlowModTime:       true,
+		ReadMimeType:            true,
+		WriteMimeType:           true,
+		BucketBased:             true,
+		BucketBasedRootOK:       true,
+		SetTier:                 true,
+		GetTier:                 true,
+		SlowModTime:             true,
+		ReadMetadata:            true,
+		WriteMetadata:           true,
+		UserMetadata:            true,
+		ServerSideAcrossConfigs: true,
 	}).Fill(ctx, f)
+	f.checkBucketKMSKey(ctx)
+	f.checkBucketReplication(ctx)
 	if f.rootBucket != "" && f.rootDirectory != "" && !strings.HasSuffix(root, "/") {
 		// Check to see if the (bucket,directory) is actually an existing file
 		oldRoot := f.root
@@ -102,8 +243,41 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 
 func checkUploadChunkSize(cs fs.SizeSuffix) error {
 	if cs < minChunkSize {
+		// only the last part of a multipart upload may be smaller
 		return fmt.Errorf("%s is less than %s", cs, minChunkSize)
 	}
+	if cs > maxChunkSize {
+		return fmt.Errorf("%s is greater than %s", cs, maxChunkSize)
+	}
+	return nil
+}
+
+var (
+	namespaceRegexp   = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+	compartmentRegexp = regexp.MustCompile(`^ocid1\.(compartment|tenancy)\.oc[0-9]+\.[a-z0-9-]*(\.[a-z0-9-]+)?\.[a-z0-9]+$`)
+)
+
+// checkNamespace checks the namespace looks like an object storage
+// namespace, so mistakes are reported before making any requests
+func checkNamespace(namespace string) error {
+	if namespace == "" {
+		return errors.New("namespace is not set: it can be found with \"oci os ns get\" or in the tenancy details of the OCI console")
+	}
+	if !namespaceRegexp.MatchString(namespace) {
+		return fmt.Errorf("not a valid namespace %q: it should only contain letters and numbers", namespace)
+	}
+	return nil
+}
+
+// checkCompartment checks the compartment looks like the OCID of a
+// compartment or tenancy, if set
+func checkCompartment(compartment string) error {
+	if compartment == "" {
+		return nil
+	}
+	if !compartmentRegexp.MatchString(compartment) {
+		return fmt.Errorf("not a valid compartment %q: it should be a compartment or tenancy OCID like ocid1.compartment.oc1..<unique_id>", compartment)
+	}
 	return nil
 }
 
@@ -111,6 +285,7 @@ func (f *Fs) setUploadChunkSize(cs fs.SizeSuffix) (old fs.SizeSuffix, err error)
 	err = checkUploadChunkSize(cs)
 	if err == nil {
 		old, f.opt.ChunkSize = f.opt.ChunkSize, cs
+		f.pool = f.newMemoryPool(int64(cs))
 	}
 	return
 }
@@ -162,13 +337,19 @@ func (f *Fs) Features() *fs.Features {
 }
 
 // Precision of the remote
+//
+// The mtime is stored in the metadata to the nanosecond so there is
+// no need to round it.
 func (f *Fs) Precision() time.Duration {
-	return time.Millisecond
+	return time.Nanosecond
 }
 
 // Hashes returns the supported hash sets.
 func (f *Fs) Hashes() hash.Set {
-	return hash.Set(hash.MD5)
+	if f.opt.DisableCrc32c {
+		return hash.Set(hash.MD5)
+	}
+	return hash.NewHashSet(hash.MD5, CRC32CHashType)
 }
 
 // setRoot changes the root of the Fs
@@ -185,11 +366,38 @@ func parsePath(path string) (root string) {
 
 // split returns bucket and bucketPath from the rootRelativePath
 // relative to f.root
+//
+// The root_prefix is added to the start of bucketPath in every bucket.
 func (f *Fs) split(rootRelativePath string) (bucketName, bucketPath string) {
-	bucketName, bucketPath = bucket.Split(path.Join(f.root, rootRelativePath))
+	bucketName, bucketPath = bucket.Split(joinPath(f.root, rootRelativePath))
+	if bucketName != "" {
+		bucketPath = joinPath(f.opt.RootPrefix, bucketPath)
+	}
 	return f.opt.Enc.FromStandardName(bucketName), f.opt.Enc.FromStandardPath(bucketPath)
 }
 
+// rootPrefix returns the start of the object names which is removed
+// to make the remotes listed, the root_prefix and the root
+// directory
+func (f *Fs) rootPrefix() string {
+	return joinPath(f.opt.RootPrefix, f.rootDirectory)
+}
+
+// joinPath joins dir and name with a "/".
+//
+// Unlike path.Join it doesn't clean the result as object names may
+// contain empty path segments, such as "dir//file", which must be kept
+// to find the object again.
+func joinPath(dir, name string) string {
+	switch {
+	case dir == "":
+		return name
+	case name == "":
+		return dir
+	}
+	return dir + "/" + name
+}
+
 // List the objects and directories in dir into entries.  The
 // entries can be returned in any order but should be for a
 // complete directory.
@@ -208,7 +416,43 @@ func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err e
 		}
 		return f.listBuckets(ctx)
 	}
-	return f.listDir(ctx, bucketName, directory, f.rootDirectory, f.rootBucket == "")
+	return f.listDir(ctx, bucketName, directory, f.rootPrefix(), f.rootBucket == "")
+}
+
+// listFields are the fields which can be requested for each object
+// in a listing, all of which are requested by default
+const listFields = "name,size,etag,timeCreated,md5,timeModified,storageTier,archivalState"
+
+// parseListFields parses the list_fields option into the fields to
+// request, which always include the name and size
+func parseListFields(fields string) (map[string]bool, error) {
+	valid := map[string]bool{}
+	for _, field := range strings.Split(listFields, ",") {
+		valid[field] = true
+	}
+	set := map[string]bool{"name": true, "size": true}
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !valid[field] {
+			return nil, fmt.Errorf("not a valid list field %q: must be one of %s", field, listFields)
+		}
+		set[field] = true
+	}
+	return set, nil
+}
+
+// listFieldsParam returns the fields parameter for listings
+func (f *Fs) listFieldsParam() string {
+	var fields []string
+	for _, field := range strings.Split(listFields, ",") {
+		if f.listFields[field] {
+			fields = append(fields, field)
+		}
+	}
+	return strings.Join(fields, ",")
 }
 
 // listFn is called from list to handle an object.
@@ -221,6 +465,7 @@ type listFn func(remote string, object *objectstorage.ObjectSummary, isDirectory
 // If addBucket is set then it adds the bucket to the start of the remotes generated
 // If recurse is set the function will recursively list
 // If limit is > 0 then it limits to that many files (must be less than 1000)
+// otherwise --oos-list-chunk files are listed in each request
 // If hidden is set then it will list the hidden (deleted) files too.
 // if findFile is set it will look for files called (bucket, directory)
 func (f *Fs) list(ctx context.Context, bucket, directory, prefix string, addBucket bool, recurse bool, limit int,
@@ -236,7 +481,7 @@ func (f *Fs) list(ctx context.Context, bucket, directory, prefix string, addBuck
 	if !recurse {
 		delimiter = "/"
 	}
-	chunkSize := 1000
+	chunkSize := f.opt.ListChunk
 	if limit > 0 {
 		chunkSize = limit
 	}
@@ -245,7 +490,7 @@ func (f *Fs) list(ctx context.Context, bucket, directory, prefix string, addBuck
 		BucketName:    common.String(bucket),
 		Prefix:        common.String(directory),
 		Limit:         common.Int(chunkSize),
-		Fields:        common.String("name,size,etag,timeCreated,md5,timeModified,storageTier,archivalState"),
+		Fields:        common.String(f.listFieldsParam()),
 	}
 	if delimiter != "" {
 		request.Delimiter = common.String(delimiter)
@@ -253,15 +498,17 @@ func (f *Fs) list(ctx context.Context, bucket, directory, prefix string, addBuck
 
 	for {
 		var resp objectstorage.ListObjectsResponse
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.Call(logRetries("ListObjects", func() (bool, error) {
+			reqCtx, cancel := f.requestContext(ctx)
+			defer cancel()
 			var err error
-			resp, err = f.srv.ListObjects(ctx, request)
+			resp, err = f.srv.ListObjects(reqCtx, request)
 			return shouldRetry(ctx, resp.HTTPResponse(), err)
-		})
+		}))
 		if err != nil {
 			if ociError, ok := err.(common.ServiceError); ok {
 				// If it is a timeout then we want to retry that
-				if ociError.GetHTTPStatusCode() == http.StatusNotFound {
+				if ociError.GetHTTPStatusCode() == http.StatusNotFound && !isWrongHost(err) {
 					err = fs.ErrorDirNotFound
 				}
 			}
@@ -292,7 +539,7 @@ func (f *Fs) list(ctx context.Context, bucket, directory, prefix string, addBuck
 				}
 				remote = remote[len(prefix):]
 				if addBucket {
-					remote = path.Join(bucket, remote)
+					remote = joinPath(bucket, remote)
 				}
 				remote = strings.TrimSuffix(remote, "/")
 				err = fn(remote, &objectstorage.ObjectSummary{Name: &remote}, true)
@@ -317,7 +564,7 @@ func (f *Fs) list(ctx context.Context, bucket, directory, prefix string, addBuck
 			// Check for directory
 			isDirectory := remote == "" || strings.HasSuffix(remote, "/")
 			if addBucket {
-				remote = path.Join(bucket, remote)
+				remote = joinPath(bucket, remote)
 			}
 			// is this a directory marker?
 			if isDirectory && object.Size != nil && *object.Size == 0 {
@@ -380,19 +627,27 @@ func (f *Fs) listDir(ctx context.Context, bucket, directory, prefix string, addB
 
 // listBuckets returns all the buckets to out
 func (f *Fs) listBuckets(ctx context.Context) (entries fs.DirEntries, err error) {
+	return f.listCompartmentBuckets(ctx, f.opt.Compartment)
+}
+
+// listCompartmentBuckets returns all the buckets in compartment, which
+// needn't be the configured one
+func (f *Fs) listCompartmentBuckets(ctx context.Context, compartment string) (entries fs.DirEntries, err error) {
 	if f.opt.Provider == noAuth {
 		return nil, fmt.Errorf("can't list buckets with %v provider, use a valid auth provider in config file", noAuth)
 	}
 	var request = objectstorage.ListBucketsRequest{
 		NamespaceName: common.String(f.opt.Namespace),
-		CompartmentId: common.String(f.opt.Compartment),
+		CompartmentId: common.String(compartment),
 	}
 	var resp objectstorage.ListBucketsResponse
 	for {
-		err = f.pacer.Call(func() (bool, error) {
-			resp, err = f.srv.ListBuckets(ctx, request)
+		err = f.pacer.Call(logRetries("ListBuckets", func() (bool, error) {
+			reqCtx, cancel := f.requestContext(ctx)
+			defer cancel()
+			resp, err = f.srv.ListBuckets(reqCtx, request)
 			return shouldRetry(ctx, resp.HTTPResponse(), err)
-		})
+		}))
 		if err != nil {
 			return nil, err
 		}
@@ -419,20 +674,28 @@ func (f *Fs) newObjectWithInfo(ctx context.Context, remote string, info *objects
 	}
 	if info != nil {
 		// Set info but not meta
-		if info.TimeModified == nil {
+		if info.TimeModified != nil {
+			o.lastModified = info.TimeModified.Time
+		} else if f.listFields["timeModified"] {
 			fs.Logf(o, "Failed to read last modified")
 			o.lastModified = time.Now()
-		} else {
-			o.lastModified = info.TimeModified.Time
 		}
-		if info.Md5 != nil {
+		if info.Md5 != nil && isMultipartMD5(*info.Md5) {
+			// don't HEAD the object for its hash as it won't have one
+			// which can be compared, so --checksum falls back to the size
+			fs.Debugf(o, "Multipart object MD5 %q isn't comparable with other hashes", *info.Md5)
+			o.multipartMD5 = true
+		} else if info.Md5 != nil {
 			md5, err := o.base64ToMd5(*info.Md5)
-			if err != nil {
+			if err == nil {
 				o.md5 = md5
 			}
 		}
 		o.bytes = *info.Size
-		o.storageTier = storageTierMap[strings.ToLower(string(info.StorageTier))]
+		o.setETag(info.Etag)
+		if f.listFields["storageTier"] {
+			o.storageTier = storageTierMap[strings.ToLower(string(info.StorageTier))]
+		}
 	} else {
 		err := o.readMetaData(ctx) // reads info and headers, returning an error
 		if err != nil {
@@ -445,9 +708,58 @@ func (f *Fs) newObjectWithInfo(ctx context.Context, remote string, info *objects
 // NewObject finds the Object at remote.  If it can't be found
 // it returns the error fs.ErrorObjectNotFound.
 func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	bucketName, bucketPath := f.split(remote)
+	if info := f.listCache.get(bucketName, bucketPath); info != nil {
+		return f.newObjectWithInfo(ctx, remote, info)
+	}
+	if f.opt.NoHeadObject {
+		info, err := f.listObject(ctx, bucketName, bucketPath)
+		if err != nil {
+			return nil, err
+		}
+		if info != nil {
+			return f.newObjectWithInfo(ctx, remote, info)
+		}
+		fs.Debugf(f, "listing didn't find %q, falling back to HEAD", remote)
+	}
 	return f.newObjectWithInfo(ctx, remote, nil)
 }
 
+// listObject finds the object called bucketPath in bucketName using a
+// listing of a single item. It returns nil if it wasn't found.
+func (f *Fs) listObject(ctx context.Context, bucketName, bucketPath string) (*objectstorage.ObjectSummary, error) {
+	if bucketName == "" || bucketPath == "" {
+		return nil, nil
+	}
+	req := objectstorage.ListObjectsRequest{
+		NamespaceName: common.String(f.opt.Namespace),
+		BucketName:    common.String(bucketName),
+		Prefix:        common.String(bucketPath),
+		Start:         common.String(bucketPath),
+		Limit:         common.Int(1),
+		Fields:        common.String(f.listFieldsParam()),
+	}
+	var resp objectstorage.ListObjectsResponse
+	err := f.pacer.Call(logRetries("ListObjects", func() (bool, error) {
+		reqCtx, cancel := f.requestContext(ctx)
+		defer cancel()
+		var err error
+		resp, err = f.srv.ListObjects(reqCtx, req)
+		return shouldRetry(ctx, resp.HTTPResponse(), err)
+	}))
+	if err != nil {
+		return nil, objectError(err, bucketName, bucketPath)
+	}
+	f.cache.MarkOK(bucketName)
+	for i := range resp.Objects {
+		object := &resp.Objects[i]
+		if object.Name != nil && *object.Name == bucketPath && object.Size != nil {
+			return object, nil
+		}
+	}
+	return nil, nil
+}
+
 // Put the object into the bucket
 // Copy the reader in to the new object which is returned
 // The new object may have been created if an error is returned
@@ -482,18 +794,8 @@ func (f *Fs) makeBucket(ctx context.Context, bucketName string) error {
 			CompartmentId:    common.String(f.opt.Compartment),
 			PublicAccessType: objectstorage.CreateBucketDetailsPublicAccessTypeNopublicaccess,
 		}
-		req := objectstorage.CreateBucketRequest{
-			NamespaceName:       common.String(f.opt.Namespace),
-			CreateBucketDetails: details,
-		}
-		err := f.pacer.Call(func() (bool, error) {
-			resp, err := f.srv.CreateBucket(ctx, req)
-			return shouldRetry(ctx, resp.HTTPResponse(), err)
-		})
-		if err == nil {
-			fs.Infof(f, "Bucket %q created with accessType %q", bucketName,
-				objectstorage.CreateBucketDetailsPublicAccessTypeNopublicaccess)
-		}
+		details.AutoTiering, _ = objectstorage.GetMappingBucketAutoTieringEnum(f.opt.BucketAutoTiering)
+		_, err := f.createBucket(ctx, details)
 		if svcErr, ok := err.(common.ServiceError); ok {
 			if code := svcErr.GetCode(); code == "BucketAlreadyOwnedByYou" || code == "BucketAlreadyExists" {
 				err = nil
@@ -505,6 +807,27 @@ func (f *Fs) makeBucket(ctx context.Context, bucketName string) error {
 	})
 }
 
+// createBucket creates the bucket described by details and returns it
+func (f *Fs) createBucket(ctx context.Context, details objectstorage.CreateBucketDetails) (*objectstorage.Bucket, error) {
+	req := objectstorage.CreateBucketRequest{
+		NamespaceName:       common.String(f.opt.Namespace),
+		CreateBucketDetails: details,
+	}
+	var resp objectstorage.CreateBucketResponse
+	err := f.pacer.Call(logRetries("CreateBucket", func() (bool, error) {
+		reqCtx, cancel := f.requestContext(ctx)
+		defer cancel()
+		var err error
+		resp, err = f.srv.CreateBucket(reqCtx, req)
+		return shouldRetry(ctx, resp.HTTPResponse(), err)
+	}))
+	if err != nil {
+		return nil, err
+	}
+	fs.Infof(f, "Bucket %q created with accessType %q", *details.Name, details.PublicAccessType)
+	return &resp.Bucket, nil
+}
+
 // Check if the bucket exists
 //
 // NB this can return incorrect results if called immediately after bucket deletion
@@ -513,10 +836,12 @@ func (f *Fs) bucketExists(ctx context.Context, bucketName string) (bool, error)
 		NamespaceName: common.String(f.opt.Namespace),
 		BucketName:    common.String(bucketName),
 	}
-	err := f.pacer.Call(func() (bool, error) {
-		resp, err := f.srv.HeadBucket(ctx, req)
+	err := f.pacer.Call(logRetries("HeadBucket", func() (bool, error) {
+		reqCtx, cancel := f.requestContext(ctx)
+		defer cancel()
+		resp, err := f.srv.HeadBucket(reqCtx, req)
 		return shouldRetry(ctx, resp.HTTPResponse(), err)
-	})
+	}))
 	if err == nil {
 		return true, nil
 	}
@@ -528,21 +853,221 @@ func (f *Fs) bucketExists(ctx context.Context, bucketName string) (bool, error)
 	return false, err
 }
 
+// getBucket reads the details of bucketName including the optional
+// fields asked for
+func (f *Fs) getBucket(ctx context.Context, bucketName string, fields ...objectstorage.GetBucketFieldsEnum) (*objectstorage.Bucket, error) {
+	req := objectstorage.GetBucketRequest{
+		NamespaceName: common.String(f.opt.Namespace),
+		BucketName:    common.String(bucketName),
+		Fields:        fields,
+	}
+	var resp objectstorage.GetBucketResponse
+	err := f.pacer.Call(logRetries("GetBucket", func() (bool, error) {
+		reqCtx, cancel := f.requestContext(ctx)
+		defer cancel()
+		var err error
+		resp, err = f.srv.GetBucket(reqCtx, req)
+		return shouldRetry(ctx, resp.HTTPResponse(), err)
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Bucket, nil
+}
+
+// checkBucketKMSKey reads the default encryption key of the root
+// bucket if sse_kms_key_id is set, so uploads to it rely on the bucket
+// default instead of sending the same key again
+func (f *Fs) checkBucketKMSKey(ctx context.Context) {
+	if f.opt.SSEKMSKeyID == "" || f.rootBucket == "" {
+		return
+	}
+	bucket, err := f.getBucket(ctx, f.rootBucket)
+	if err != nil {
+		fs.Debugf(f, "Couldn't read the default encryption key of bucket %q: %v", f.rootBucket, err)
+		return
+	}
+	switch {
+	case bucket.KmsKeyId == nil || *bucket.KmsKeyId == "":
+		fs.Debugf(f, "Bucket %q has no default encryption key, encrypting uploads with %s", f.rootBucket, f.opt.SSEKMSKeyID)
+	case *bucket.KmsKeyId == f.opt.SSEKMSKeyID:
+		fs.Debugf(f, "Bucket %q has default encryption key %s, relying on it for uploads", f.rootBucket, *bucket.KmsKeyId)
+		f.bucketKMSKey = true
+	default:
+		fs.Infof(f, "Bucket %q has default encryption key %s, encrypting uploads with %s instead", f.rootBucket, *bucket.KmsKeyId, f.opt.SSEKMSKeyID)
+	}
+}
+
+// checkBucketReplication reads the replication status of the root
+// bucket if check_replication is set and warns if it is the source or
+// destination of a replication policy
+func (f *Fs) checkBucketReplication(ctx context.Context) {
+	if !f.opt.CheckReplication || f.rootBucket == "" {
+		return
+	}
+	bucket, err := f.getBucket(ctx, f.rootBucket)
+	if err != nil {
+		fs.Debugf(f, "Couldn't read the replication status of bucket %q: %v", f.rootBucket, err)
+		return
+	}
+	if bucket.ReplicationEnabled != nil && *bucket.ReplicationEnabled {
+		fs.Logf(f, "Bucket %q is the source of a replication policy: objects deleted from it, eg by sync --delete, are deleted from its replicas too", f.rootBucket)
+	}
+	if bucket.IsReadOnly != nil && *bucket.IsReadOnly {
+		fs.Logf(f, "Bucket %q is the destination of a replication policy so it is read only: uploads and deletes will fail", f.rootBucket)
+	}
+}
+
+// kmsKeyID returns the encryption key to send with uploads to
+// bucketName or nil to use the bucket's default
+func (f *Fs) kmsKeyID(bucketName string) *string {
+	if f.opt.SSEKMSKeyID == "" || (f.bucketKMSKey && bucketName == f.rootBucket) {
+		return nil
+	}
+	return common.String(f.opt.SSEKMSKeyID)
+}
+
+// sseCustomerKeyLength is the size of an SSE-C key in bytes
+const sseCustomerKeyLength = 32
+
+// sseCustomerKey holds the headers which encrypt objects with a key
+// supplied by the customer (SSE-C)
+type sseCustomerKey struct {
+	algorithm string // always AES256
+	key       string // base64 encoded key
+	keySha256 string // base64 encoded SHA256 of the key
+}
+
+// readSSECustomerKeyFile reads the raw SSE-C key from path and works
+// out the headers to send it with
+func readSSECustomerKeyFile(path string) (*sseCustomerKey, error) {
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sse_customer_key_file: %w", err)
+	}
+	if len(key) != sseCustomerKeyLength {
+		return nil, fmt.Errorf("sse_customer_key_file %q must contain a %d byte key, got %d bytes", path, sseCustomerKeyLength, len(key))
+	}
+	sum := sha256.Sum256(key)
+	return &sseCustomerKey{
+		algorithm: "AES256",
+		key:       base64.StdEncoding.EncodeToString(key),
+		keySha256: base64.StdEncoding.EncodeToString(sum[:]),
+	}, nil
+}
+
+// headers returns the algorithm, key and SHA256 of the key to set in
+// object requests, which are all nil if k is nil
+func (k *sseCustomerKey) headers() (algorithm, key, keySha256 *string) {
+	if k == nil {
+		return nil, nil, nil
+	}
+	return common.String(k.algorithm), common.String(k.key), common.String(k.keySha256)
+}
+
+// getBucketAutoTiering returns the auto-tiering state of the bucket
+func (f *Fs) getBucketAutoTiering(ctx context.Context, bucketName string) (string, error) {
+	bucket, err := f.getBucket(ctx, bucketName, objectstorage.GetBucketFieldsAutotiering)
+	if err != nil {
+		return "", err
+	}
+	if bucket.AutoTiering == "" {
+		return string(objectstorage.BucketAutoTieringDisabled), nil
+	}
+	return string(bucket.AutoTiering), nil
+}
+
+// bucketInfo is the summary of a bucket returned by the bucket-info
+// backend command
+type bucketInfo struct {
+	Name               string `json:"name"`
+	Namespace          string `json:"namespace"`
+	CompartmentID      string `json:"compartmentId"`
+	PublicAccessType   string `json:"publicAccessType"`
+	StorageTier        string `json:"storageTier"`
+	Versioning         string `json:"versioning"`
+	AutoTiering        string `json:"autoTiering"`
+	ApproximateCount   int64  `json:"approximateCount"`
+	ApproximateSize    int64  `json:"approximateSize"`
+	ReplicationEnabled bool   `json:"replicationEnabled"`
+	IsReadOnly         bool   `json:"isReadOnly"`
+}
+
+// newBucketInfo makes the bucket-info summary of bucket
+func newBucketInfo(bucket *objectstorage.Bucket) *bucketInfo {
+	info := &bucketInfo{
+		PublicAccessType: string(bucket.PublicAccessType),
+		StorageTier:      string(bucket.StorageTier),
+		Versioning:       string(bucket.Versioning),
+		AutoTiering:      string(bucket.AutoTiering),
+	}
+	if info.AutoTiering == "" {
+		info.AutoTiering = string(objectstorage.BucketAutoTieringDisabled)
+	}
+	if bucket.Name != nil {
+		info.Name = *bucket.Name
+	}
+	if bucket.Namespace != nil {
+		info.Namespace = *bucket.Namespace
+	}
+	if bucket.CompartmentId != nil {
+		info.CompartmentID = *bucket.CompartmentId
+	}
+	if bucket.ApproximateCount != nil {
+		info.ApproximateCount = *bucket.ApproximateCount
+	}
+	if bucket.ApproximateSize != nil {
+		info.ApproximateSize = *bucket.ApproximateSize
+	}
+	if bucket.ReplicationEnabled != nil {
+		info.ReplicationEnabled = *bucket.ReplicationEnabled
+	}
+	if bucket.IsReadOnly != nil {
+		info.IsReadOnly = *bucket.IsReadOnly
+	}
+	return info
+}
+
+// getBucketInfo returns the bucket-info summary of bucketName
+func (f *Fs) getBucketInfo(ctx context.Context, bucketName string) (*bucketInfo, error) {
+	bucket, err := f.getBucket(ctx, bucketName,
+		objectstorage.GetBucketFieldsApproximatecount,
+		objectstorage.GetBucketFieldsApproximatesize,
+		objectstorage.GetBucketFieldsAutotiering)
+	if err != nil {
+		return nil, err
+	}
+	return newBucketInfo(bucket), nil
+}
+
 // Rmdir delete an empty bucket. if bucket is not empty this is will fail with appropriate error
+//
+// Directories aren't stored so removing one only deletes its directory
+// marker, if another tool made one.
 func (f *Fs) Rmdir(ctx context.Context, dir string) error {
 	bucketName, directory := f.split(dir)
-	if bucketName == "" || directory != "" {
+	if bucketName == "" {
 		return nil
 	}
+	if directory != "" {
+		return f.deleteObject(ctx, bucketName, directory+"/")
+	}
+	return f.deleteBucket(ctx, bucketName)
+}
+
+// deleteBucket deletes bucketName, which must be empty
+func (f *Fs) deleteBucket(ctx context.Context, bucketName string) error {
 	return f.cache.Remove(bucketName, func() error {
 		req := objectstorage.DeleteBucketRequest{
 			NamespaceName: common.String(f.opt.Namespace),
 			BucketName:    common.String(bucketName),
 		}
-		err := f.pacer.Call(func() (bool, error) {
-			resp, err := f.srv.DeleteBucket(ctx, req)
+		err := f.pacer.Call(logRetries("DeleteBucket", func() (bool, error) {
+			reqCtx, cancel := f.requestContext(ctx)
+			defer cancel()
+			resp, err := f.srv.DeleteBucket(reqCtx, req)
 			return shouldRetry(ctx, resp.HTTPResponse(), err)
-		})
+		}))
 		if err == nil {
 			fs.Infof(f, "Bucket %q deleted", bucketName)
 		}
@@ -560,10 +1085,30 @@ func (f *Fs) abortMultiPartUpload(ctx context.Context, bucketName, bucketPath, u
 		ObjectName:    common.String(bucketPath),
 		UploadId:      common.String(uploadID),
 	}
-	err = f.pacer.Call(func() (bool, error) {
-		resp, err := f.srv.AbortMultipartUpload(ctx, request)
+	err = f.pacer.Call(logRetries("AbortMultipartUpload", func() (bool, error) {
+		reqCtx, cancel := f.requestContext(ctx)
+		defer cancel()
+		resp, err := f.srv.AbortMultipartUpload(reqCtx, request)
 		return shouldRetry(ctx, resp.HTTPResponse(), err)
-	})
+	}))
+	return err
+}
+
+// Shutdown the backend, aborting any multipart uploads still in
+// progress so their parts aren't left behind, unless
+// --oos-leave-parts-on-error is set.
+func (f *Fs) Shutdown(ctx context.Context) (err error) {
+	f.uploadsMu.Lock()
+	ids := make([]string, 0, len(f.uploads))
+	for uploadID := range f.uploads {
+		ids = append(ids, uploadID)
+	}
+	f.uploadsMu.Unlock()
+	for _, uploadID := range ids {
+		if abortErr := f.abortUpload(ctx, uploadID); abortErr != nil {
+			err = abortErr
+		}
+	}
 	return err
 }
 
@@ -643,6 +1188,9 @@ func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (
 	list := walk.NewListRHelper(callback)
 	listR := func(bucket, directory, prefix string, addBucket bool) error {
 		return f.list(ctx, bucket, directory, prefix, addBucket, true, 0, func(remote string, object *objectstorage.ObjectSummary, isDirectory bool) error {
+			if !isDirectory {
+				f.listCache.put(bucket, *object.Name, object)
+			}
 			entry, err := f.itemToDirEntry(ctx, remote, object, isDirectory)
 			if err != nil {
 				return err
@@ -660,8 +1208,8 @@ func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (
 			if err != nil {
 				return err
 			}
-			bucketName := entry.Remote()
-			err = listR(bucketName, "", f.rootDirectory, true)
+			bucketName, directory := f.split(entry.Remote())
+			err = listR(bucketName, directory, f.rootPrefix(), true)
 			if err != nil {
 				return err
 			}
@@ -669,7 +1217,7 @@ func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (
 			f.cache.MarkOK(bucketName)
 		}
 	} else {
-		err = listR(bucketName, directory, f.rootDirectory, f.rootBucket == "")
+		err = listR(bucketName, directory, f.rootPrefix(), f.rootBucket == "")
 		if err != nil {
 			return err
 		}
@@ -683,13 +1231,19 @@ func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (
 var (
 	_ fs.Fs          = &Fs{}
 	_ fs.Copier      = &Fs{}
+	_ fs.DirMover    = &Fs{}
+	_ fs.Mover       = &Fs{}
+	_ fs.Purger      = &Fs{}
 	_ fs.PutStreamer = &Fs{}
 	_ fs.ListRer     = &Fs{}
 	_ fs.Commander   = &Fs{}
 	_ fs.CleanUpper  = &Fs{}
+	_ fs.Shutdowner  = &Fs{}
 
-	_ fs.Object    = &Object{}
-	_ fs.MimeTyper = &Object{}
-	_ fs.GetTierer = &Object{}
-	_ fs.SetTierer = &Object{}
+	_ fs.Object     = &Object{}
+	_ fs.MimeTyper  = &Object{}
+	_ fs.Metadataer = &Object{}
+	_ fs.GetTierer  = &Object{}
+	_ fs.SetTierer  = &Object{}
+	_ fs.IDer       = &Object{}
 )
@@ -0,0 +1,4713 @@
+//go:build !plan9 && !solaris && !js
+// +build !plan9,!solaris,!js
+
+package oracleobjectstorage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	_ "github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/fshttp"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/walk"
+	"github.com/rclone/rclone/lib/pacer"
+	"github.com/rclone/rclone/lib/random"
+	"github.com/rclone/rclone/lib/readers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer is a mock object storage server recording the requests
+// it receives
+type testServer struct {
+	mu       sync.Mutex
+	requests []string // "METHOD path" of each request received
+	handler  http.HandlerFunc
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	s.mu.Unlock()
+	s.handler(w, r)
+}
+
+// count returns the number of requests received with method
+func (s *testServer) count(method string) (n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, request := range s.requests {
+		if len(request) > len(method) && request[:len(method)+1] == method+" " {
+			n++
+		}
+	}
+	return n
+}
+
+// newTestFs makes an Fs with the no_auth provider pointing at a mock
+// object storage server which calls handler for each request
+func newTestFs(t testing.TB, root string, handler http.HandlerFunc, config configmap.Simple) (*Fs, *testServer) {
+	ts := &testServer{handler: handler}
+	server := httptest.NewServer(ts)
+	t.Cleanup(server.Close)
+	m := configmap.Simple{
+		"provider":  noAuth,
+		"namespace": "testns",
+		"region":    "us-ashburn-1",
+		"endpoint":  server.URL,
+	}
+	for k, v := range config {
+		m[k] = v
+	}
+	// chunk sizes below the minimum are set after NewFs, which
+	// rejects them, so the tests can upload small parts
+	var chunkSize fs.SizeSuffix
+	if cs, ok := m["chunk_size"]; ok {
+		require.NoError(t, chunkSize.Set(cs))
+		delete(m, "chunk_size")
+	}
+	regInfo, err := fs.Find("oracleobjectstorage")
+	require.NoError(t, err)
+	f, err := NewFs(context.Background(), "TestOOS", root, fs.ConfigMap(regInfo, "TestOOS", m))
+	require.NoError(t, err)
+	ff := f.(*Fs)
+	ff.pacer.SetRetries(1)
+	if chunkSize > 0 {
+		ff.opt.ChunkSize = chunkSize
+		ff.pool = ff.newMemoryPool(int64(chunkSize))
+	}
+	return ff, ts
+}
+
+// putTestObject uploads contents to remote on f
+func putTestObject(t *testing.T, f *Fs, remote string, contents []byte) fs.Object {
+	ctx := context.Background()
+	src := object.NewStaticObjectInfo(remote, time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC), int64(len(contents)), true, nil, f)
+	o, err := f.Put(ctx, bytes.NewReader(contents), src)
+	require.NoError(t, err)
+	return o
+}
+
+// testServiceError is a minimal common.ServiceError for tests
+type testServiceError struct {
+	status    int
+	code      string
+	requestID string
+}
+
+func (e testServiceError) Error() string {
+	return fmt.Sprintf("service error %d %s", e.status, e.code)
+}
+func (e testServiceError) GetHTTPStatusCode() int  { return e.status }
+func (e testServiceError) GetMessage() string      { return e.code }
+func (e testServiceError) GetCode() string         { return e.code }
+func (e testServiceError) GetOpcRequestID() string { return e.requestID }
+
+func testResponse(status int, headers map[string]string) *http.Response {
+	resp := &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+		Request:    &http.Request{Header: http.Header{}},
+	}
+	for k, v := range headers {
+		resp.Header.Set(k, v)
+	}
+	return resp
+}
+
+func TestErrorWithRequestID(t *testing.T) {
+	resp := testResponse(http.StatusConflict, map[string]string{
+		"opc-request-id":        "req-1234",
+		"opc-client-request-id": "client-5678",
+	})
+
+	// service errors are annotated but still type assert
+	err := errorWithRequestID(testServiceError{status: http.StatusConflict, code: "Conflict"}, resp)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "opc-request-id: req-1234")
+	assert.Contains(t, err.Error(), "opc-client-request-id: client-5678")
+	svcErr, ok := err.(common.ServiceError)
+	require.True(t, ok)
+	assert.Equal(t, "Conflict", svcErr.GetCode())
+
+	// other errors are wrapped
+	plainErr := errors.New("boom")
+	err = errorWithRequestID(plainErr, resp)
+	assert.Contains(t, err.Error(), "opc-request-id: req-1234")
+	assert.True(t, errors.Is(err, plainErr))
+
+	// ids already in the message aren't repeated
+	err = errorWithRequestID(errors.New("failed, opc request id: req-1234"), testResponse(http.StatusConflict, map[string]string{
+		"opc-request-id": "req-1234",
+	}))
+	assert.Equal(t, "failed, opc request id: req-1234", err.Error())
+
+	// nothing to add without a response
+	assert.Equal(t, plainErr, errorWithRequestID(plainErr, nil))
+	assert.NoError(t, errorWithRequestID(nil, resp))
+}
+
+func TestErrorWithHostHint(t *testing.T) {
+	resp := testResponse(http.StatusNotFound, nil)
+	resp.Request.URL = &url.URL{Host: "objectstorage.eu-frankfurt-1.oraclecloud.com", Path: "/n/othertenancy/b/bucket/o"}
+
+	// a namespace which isn't at the host gets the hint but still type asserts
+	err := errorWithHostHint(testServiceError{status: http.StatusNotFound, code: "NamespaceNotFound"}, resp)
+	assert.EqualError(t, err, `namespace "othertenancy" not found at "objectstorage.eu-frankfurt-1.oraclecloud.com", `+
+		`check the namespace and the region or endpoint are from the same tenancy: service error 404 NamespaceNotFound`)
+	svcErr, ok := err.(common.ServiceError)
+	require.True(t, ok)
+	assert.Equal(t, "NamespaceNotFound", svcErr.GetCode())
+	assert.True(t, isWrongHost(err))
+	assert.True(t, isWrongHost(errorWithRequestID(err, testResponse(http.StatusNotFound, map[string]string{"opc-request-id": "req-1"}))))
+	assert.NotErrorIs(t, objectError(err, "bucket", "file.txt"), fs.ErrorObjectNotFound)
+
+	// a host which doesn't exist
+	dnsErr := &url.Error{Op: "Get", URL: "https://objectstorage.us-nowhere-1.oraclecloud.com/n/ns/b/bucket/o", Err: &net.DNSError{
+		Err:        "no such host",
+		Name:       "objectstorage.us-nowhere-1.oraclecloud.com",
+		IsNotFound: true,
+	}}
+	err = errorWithHostHint(dnsErr, nil)
+	assert.ErrorContains(t, err, `host "objectstorage.us-nowhere-1.oraclecloud.com" not found, check the region or endpoint is right`)
+	assert.ErrorIs(t, err, dnsErr)
+
+	// other errors are unchanged
+	for _, other := range []error{
+		nil,
+		errors.New("boom"),
+		testServiceError{status: http.StatusNotFound, code: "ObjectNotFound"},
+		testServiceError{status: http.StatusConflict, code: "NamespaceNotFound"},
+	} {
+		assert.Equal(t, other, errorWithHostHint(other, resp))
+		assert.False(t, isWrongHost(other))
+	}
+}
+
+func TestWrongNamespace(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code":"NamespaceNotFound","message":"You do not have authorization to perform this request, or the requested resource could not be found."}`))
+	}
+	f, _ := newTestFs(t, "bucket", handler, nil)
+	_, err := f.List(context.Background(), "")
+	assert.NotErrorIs(t, err, fs.ErrorDirNotFound)
+	assert.ErrorContains(t, err, `namespace "testns" not found at`)
+}
+
+func TestShouldRetryAddsRequestID(t *testing.T) {
+	resp := testResponse(http.StatusServiceUnavailable, map[string]string{
+		"opc-request-id": "req-retry",
+	})
+	retry, err := shouldRetry(context.Background(), resp, testServiceError{status: http.StatusServiceUnavailable, code: "ServiceUnavailable"})
+	assert.True(t, retry)
+	assert.Contains(t, err.Error(), "opc-request-id: req-retry")
+}
+
+func TestClientRequestIDPrefix(t *testing.T) {
+	var ids []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get("opc-client-request-id"))
+		w.Header().Set("opc-client-request-id", r.Header.Get("opc-client-request-id"))
+		w.WriteHeader(http.StatusConflict)
+	}
+	f, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+		"client_request_id_prefix": "tenant-a",
+	})
+	_, err := f.NewObject(context.Background(), "file.txt")
+	require.Error(t, err)
+	_, err = f.NewObject(context.Background(), "file.txt")
+	require.Error(t, err)
+	require.Len(t, ids, 2)
+	for _, id := range ids {
+		assert.Regexp(t, `^tenant-a-[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`, id)
+	}
+	assert.NotEqual(t, ids[0], ids[1])
+	assert.Contains(t, err.Error(), ids[1])
+}
+
+// testTimeoutError is a net.Error which timed out
+type testTimeoutError struct{}
+
+func (testTimeoutError) Error() string   { return "i/o timeout" }
+func (testTimeoutError) Timeout() bool   { return true }
+func (testTimeoutError) Temporary() bool { return false }
+
+func TestLogRetries(t *testing.T) {
+	ci := fs.GetConfig(context.Background())
+	oldLogLevel := ci.LogLevel
+	ci.LogLevel = fs.LogLevelInfo
+	var logs []string
+	oldLogPrint := fs.LogPrint
+	fs.LogPrint = func(level fs.LogLevel, text string) {
+		logs = append(logs, text)
+	}
+	defer func() {
+		ci.LogLevel = oldLogLevel
+		fs.LogPrint = oldLogPrint
+	}()
+
+	p := pacer.New(pacer.RetriesOption(3), pacer.CalculatorOption(pacer.NewDefault(pacer.MinSleep(time.Millisecond))))
+	calls := 0
+	err := p.Call(logRetries("PutObject", func() (bool, error) {
+		calls++
+		switch calls {
+		case 1:
+			return true, testServiceError{status: http.StatusServiceUnavailable, code: "ServiceUnavailable"}
+		case 2:
+			return true, fmt.Errorf("Put \"https://example.com\": %w\nmore detail", io.ErrUnexpectedEOF)
+		}
+		return false, nil
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []string{
+		"oos: PutObject: retrying after attempt 1: 503 ServiceUnavailable",
+		"oos: PutObject: retrying after attempt 2: Put \"https://example.com\": unexpected EOF",
+	}, logs)
+
+	// no log when the call isn't retried
+	logs = nil
+	err = p.Call(logRetries("HeadObject", func() (bool, error) {
+		return false, testServiceError{status: http.StatusNotFound, code: "ObjectNotFound"}
+	}))
+	require.Error(t, err)
+	assert.Empty(t, logs)
+}
+
+func TestShouldRetryConnectionErrors(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name string
+		err  error
+	}{
+		{"unexpected EOF", io.ErrUnexpectedEOF},
+		{"wrapped unexpected EOF", fmt.Errorf("reading body: %w", io.ErrUnexpectedEOF)},
+		{"timeout", &url.Error{Op: "Get", URL: "https://example.com", Err: testTimeoutError{}}},
+		{"connection reset", &url.Error{Op: "Put", URL: "https://example.com", Err: &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}}},
+		{"connection aborted", &net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.ECONNABORTED)}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			retry, err := shouldRetry(ctx, nil, test.err)
+			assert.True(t, retry)
+			assert.Equal(t, test.err, err)
+
+			// committing a multipart upload mustn't be retried as it may have succeeded
+			retry, _ = shouldRetryNotIdempotent(ctx, nil, test.err)
+			assert.False(t, retry)
+		})
+	}
+
+	retry, _ := shouldRetry(ctx, nil, errors.New("permission denied"))
+	assert.False(t, retry)
+
+	// messages which just mention a connection error aren't retried
+	retry, _ = shouldRetry(ctx, nil, errors.New("invalid metadata: unexpected EOF"))
+	assert.False(t, retry)
+
+	// unless the server said it didn't process it
+	resp := testResponse(http.StatusServiceUnavailable, nil)
+	retry, _ = shouldRetryNotIdempotent(ctx, resp, testServiceError{status: http.StatusServiceUnavailable, code: "ServiceUnavailable"})
+	assert.True(t, retry)
+	resp = testResponse(http.StatusInternalServerError, nil)
+	retry, _ = shouldRetryNotIdempotent(ctx, resp, testServiceError{status: http.StatusInternalServerError, code: "InternalServerError"})
+	assert.False(t, retry)
+
+	// a cancelled context is never retried
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	retry, _ = shouldRetry(cancelCtx, nil, io.ErrUnexpectedEOF)
+	assert.False(t, retry)
+}
+
+func TestNoCheckBucket(t *testing.T) {
+	ctx := context.Background()
+	for _, noCheckBucket := range []bool{false, true} {
+		t.Run(fmt.Sprint(noCheckBucket), func(t *testing.T) {
+			var bucketCalls []string
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				path := strings.TrimPrefix(r.URL.Path, "/n/testns/b")
+				switch {
+				case r.URL.Path == "/workRequests/wr1":
+					_, _ = w.Write([]byte(`{"id":"wr1","status":"COMPLETED","percentComplete":100}`))
+				case path == "" || !strings.Contains(path[1:], "/"):
+					bucketCalls = append(bucketCalls, r.Method+" "+r.URL.Path)
+					_, _ = w.Write([]byte(`{"name":"bucket"}`))
+				case r.Method == http.MethodPut:
+					w.Header().Set("ETag", "etag")
+				case r.Method == http.MethodPost && path == "/bucket/actions/copyObject":
+					w.Header().Set("opc-work-request-id", "wr1")
+				case r.Method == http.MethodHead:
+					w.Header().Set("Content-Length", "5")
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}
+			f, _ := newTestFs(t, "", handler, configmap.Simple{
+				"no_check_bucket": fmt.Sprint(noCheckBucket),
+			})
+			require.NoError(t, f.Mkdir(ctx, "bucket"))
+			o := putTestObject(t, f, "bucket/file.txt", []byte("hello"))
+			_, err := f.Copy(ctx, o, "other/copy.txt")
+			require.NoError(t, err)
+			if noCheckBucket {
+				assert.Empty(t, bucketCalls)
+			} else {
+				assert.Equal(t, []string{"POST /n/testns/b", "POST /n/testns/b"}, bucketCalls)
+			}
+		})
+	}
+}
+
+func TestNoHead(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			w.Header().Set("ETag", "etag-1")
+			w.Header().Set("opc-content-md5", "XUFAKrxLKna5cZ2REBfFkg==")
+			w.Header().Set("last-modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		case http.MethodHead:
+			w.Header().Set("Content-Length", "5")
+			w.Header().Set("Content-MD5", "XUFAKrxLKna5cZ2REBfFkg==")
+			w.Header().Set("last-modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			w.Header().Set("opc-meta-mtime", "981173106")
+		}
+	}
+	for _, noHead := range []bool{false, true} {
+		t.Run(fmt.Sprint(noHead), func(t *testing.T) {
+			f, ts := newTestFs(t, "bucket", handler, configmap.Simple{
+				"no_check_bucket": "true",
+				"no_head":         fmt.Sprint(noHead),
+			})
+			o := putTestObject(t, f, "file.txt", []byte("hello"))
+			assert.Equal(t, 1, ts.count(http.MethodPut))
+			if noHead {
+				assert.Equal(t, 0, ts.count(http.MethodHead))
+			} else {
+				assert.Equal(t, 1, ts.count(http.MethodHead))
+			}
+			assert.Equal(t, int64(5), o.Size())
+			md5, err := o.Hash(context.Background(), hash.MD5)
+			require.NoError(t, err)
+			assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", md5)
+			assert.Equal(t, time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC), o.ModTime(context.Background()).UTC())
+		})
+	}
+}
+
+func TestModTimeNanoseconds(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{"no_check_bucket": "true"})
+	modTime := time.Date(2001, 2, 3, 4, 5, 6, 123456789, time.UTC)
+	src := object.NewStaticObjectInfo("file.txt", modTime, 5, true, nil, f)
+	_, err := f.Put(ctx, strings.NewReader("hello"), src)
+	require.NoError(t, err)
+	assert.Equal(t, "981173106.123456789", store.meta["file.txt"].Get(ociMetaPrefix+metaMtime))
+
+	o, err := f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	assert.True(t, modTime.Equal(o.ModTime(ctx)), "got %v", o.ModTime(ctx))
+
+	// the precision mustn't widen --modify-window
+	assert.Equal(t, time.Nanosecond, f.Precision())
+	ctx, ci := fs.AddConfig(ctx)
+	ci.ModifyWindow = time.Second
+	assert.Equal(t, time.Second, fs.GetModifyWindow(ctx, f))
+	ci.ModifyWindow = time.Nanosecond
+	assert.Equal(t, time.Nanosecond, fs.GetModifyWindow(ctx, f))
+}
+
+func TestNoAuthPublicBucket(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		// requests to public buckets mustn't be signed
+		assert.Empty(t, r.Header.Get("Authorization"), r.URL.Path)
+		assert.Empty(t, r.Header.Get("x-content-sha256"), r.URL.Path)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/o":
+			_, _ = w.Write([]byte(`{"objects":[{"name":"file.txt","size":5,"timeModified":"2006-01-02T15:04:05Z"}]}`))
+		case r.Method == http.MethodHead && r.URL.Path == "/n/testns/b/bucket/o/file.txt":
+			w.Header().Set("Content-Length", "5")
+			w.Header().Set("last-modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/o/file.txt":
+			w.Header().Set("Content-Length", "5")
+			_, _ = w.Write([]byte("hello"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	ctx := context.Background()
+	// no compartment is needed
+	f, ts := newTestFs(t, "bucket", handler, configmap.Simple{"compartment": ""})
+	assert.IsType(t, &noAuthSigner{}, f.srv.Signer)
+
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "file.txt", entries[0].Remote())
+
+	o, err := f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	data, err := io.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "hello", string(data))
+	assert.Equal(t, []string{
+		"GET /n/testns/b/bucket/o",
+		"HEAD /n/testns/b/bucket/o/file.txt",
+		"GET /n/testns/b/bucket/o/file.txt",
+	}, ts.requests)
+
+	// listing the buckets needs credentials
+	f, _ = newTestFs(t, "", handler, nil)
+	_, err = f.List(ctx, "")
+	assert.ErrorContains(t, err, "can't list buckets with no_auth provider")
+}
+
+func TestNoHeadObject(t *testing.T) {
+	found := true
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/o":
+			assert.Equal(t, "1", r.URL.Query().Get("limit"))
+			assert.Equal(t, "file.txt", r.URL.Query().Get("prefix"))
+			if !found {
+				_, _ = w.Write([]byte(`{"objects":[]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"objects":[{"name":"file.txt","size":5,"md5":"XUFAKrxLKna5cZ2REBfFkg==",` +
+				`"timeModified":"2006-01-02T15:04:05Z","storageTier":"InfrequentAccess"}]}`))
+		case r.Method == http.MethodHead:
+			w.Header().Set("Content-Length", "5")
+			w.Header().Set("Content-MD5", "XUFAKrxLKna5cZ2REBfFkg==")
+			w.Header().Set("last-modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			w.Header().Set("storage-tier", "InfrequentAccess")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	ctx := context.Background()
+	newObject := func(noHeadObject bool) (*Object, *testServer) {
+		f, ts := newTestFs(t, "bucket", handler, configmap.Simple{
+			"no_head_object": fmt.Sprint(noHeadObject),
+		})
+		o, err := f.NewObject(ctx, "file.txt")
+		require.NoError(t, err)
+		return o.(*Object), ts
+	}
+
+	headObj, ts := newObject(false)
+	assert.Equal(t, 1, ts.count(http.MethodHead))
+	assert.Equal(t, 0, ts.count(http.MethodGet))
+
+	listObj, ts := newObject(true)
+	assert.Equal(t, 0, ts.count(http.MethodHead))
+	assert.Equal(t, 1, ts.count(http.MethodGet))
+
+	assert.Equal(t, headObj.bytes, listObj.bytes)
+	assert.Equal(t, headObj.md5, listObj.md5)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", listObj.md5)
+	assert.True(t, headObj.lastModified.Equal(listObj.lastModified))
+	assert.Equal(t, headObj.GetTier(), listObj.GetTier())
+	assert.Equal(t, infrequentAccess, listObj.GetTier())
+
+	// falls back to HEAD if the listing doesn't find the object
+	found = false
+	fallbackObj, ts := newObject(true)
+	assert.Equal(t, 1, ts.count(http.MethodGet))
+	assert.Equal(t, 1, ts.count(http.MethodHead))
+	assert.Equal(t, headObj.bytes, fallbackObj.bytes)
+}
+
+func TestListCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	f, ts := newTestFs(t, "bucket", store.handler, configmap.Simple{
+		"no_check_bucket": "true",
+		"list_cache_ttl":  "1m",
+	})
+	for _, remote := range []string{"dir/a.txt", "dir/b.txt"} {
+		putTestObject(t, f, remote, []byte(remote))
+	}
+	heads := ts.count(http.MethodHead)
+
+	// a warmed cache finds the objects without a HEAD
+	err := f.ListR(ctx, "", func(entries fs.DirEntries) error { return nil })
+	require.NoError(t, err)
+	o, err := f.NewObject(ctx, "dir/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("dir/a.txt")), o.Size())
+	assert.Equal(t, heads, ts.count(http.MethodHead))
+
+	// objects which have been removed are forgotten
+	require.NoError(t, o.Remove(ctx))
+	_, err = f.NewObject(ctx, "dir/a.txt")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+	assert.Equal(t, heads+1, ts.count(http.MethodHead))
+
+	// as are those which expire
+	f.listCache.ttl = 0
+	err = f.ListR(ctx, "", func(entries fs.DirEntries) error { return nil })
+	require.NoError(t, err)
+	_, err = f.NewObject(ctx, "dir/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, heads+2, ts.count(http.MethodHead))
+
+	// and nothing is cached by default
+	f, ts = newTestFs(t, "bucket", store.handler, configmap.Simple{"no_check_bucket": "true"})
+	assert.Nil(t, f.listCache)
+	err = f.ListR(ctx, "", func(entries fs.DirEntries) error { return nil })
+	require.NoError(t, err)
+	_, err = f.NewObject(ctx, "dir/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, 1, ts.count(http.MethodHead))
+}
+
+func TestHTTPClientTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "5")
+		w.Header().Set("last-modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+	}))
+	defer server.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, caPEM, 0600))
+
+	fshttp.ResetTransport()
+	defer fshttp.ResetTransport()
+	ctx, ci := fs.AddConfig(context.Background())
+	ci.CaCert = []string{caFile}
+	opt := &Options{Provider: noAuth, Namespace: "testns", Region: "us-ashburn-1"}
+	client, err := newObjectStorageClient(ctx, opt)
+	require.NoError(t, err)
+
+	// the transport is rclone's with the CA bundle loaded
+	httpClient, ok := client.HTTPClient.(*http.Client)
+	require.True(t, ok)
+	transport, ok := httpClient.Transport.(*fshttp.Transport)
+	require.True(t, ok)
+	require.NotNil(t, transport.TLSClientConfig.RootCAs)
+
+	// the SDK auth clients are given the same http client
+	dispatcher, err := httpDispatcherModifier(ctx)(nil)
+	require.NoError(t, err)
+	assert.Equal(t, transport, dispatcher.(*http.Client).Transport)
+
+	// so requests to a server signed by the CA succeed
+	client.Host = server.URL
+	_, err = client.HeadObject(ctx, objectstorage.HeadObjectRequest{
+		NamespaceName: common.String("testns"),
+		BucketName:    common.String("bucket"),
+		ObjectName:    common.String("file.txt"),
+	})
+	assert.NoError(t, err)
+}
+
+func TestDisableHTTP2(t *testing.T) {
+	ctx, ci := fs.AddConfig(context.Background())
+	ci.Cookie = true
+	for _, disable := range []bool{false, true} {
+		t.Run(fmt.Sprint(disable), func(t *testing.T) {
+			opt := &Options{Provider: noAuth, Namespace: "testns", Region: "us-ashburn-1", DisableHTTP2: disable}
+			client, err := newObjectStorageClient(ctx, opt)
+			require.NoError(t, err)
+			httpClient, ok := client.HTTPClient.(*http.Client)
+			require.True(t, ok)
+			// the rest of the client is set up as usual
+			assert.NotNil(t, httpClient.Jar)
+			transport, ok := httpClient.Transport.(*fshttp.Transport)
+			require.True(t, ok)
+			assert.Equal(t, !disable, transport.ForceAttemptHTTP2)
+			if disable {
+				// a non nil empty map stops HTTP/2 being negotiated
+				assert.NotNil(t, transport.TLSNextProto)
+				assert.Empty(t, transport.TLSNextProto)
+			}
+		})
+	}
+}
+
+func TestIMDSDispatcher(t *testing.T) {
+	var paths, tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		if !strings.HasPrefix(r.URL.Path, "/opc/v2/") {
+			// hardened instances don't serve IMDSv1
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("us-ashburn-1"))
+	}))
+	defer server.Close()
+	oldIMDSHost := imdsHost
+	imdsHost = strings.TrimPrefix(server.URL, "http://")
+	defer func() { imdsHost = oldIMDSHost }()
+
+	dispatcher, err := imdsDispatcherModifier(context.Background())(nil)
+	require.NoError(t, err)
+	for _, version := range []string{"v2", "v1"} {
+		req, err := http.NewRequest(http.MethodGet, "http://"+imdsHost+"/opc/"+version+"/instance/region", nil)
+		require.NoError(t, err)
+		resp, err := dispatcher.Do(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, resp.Body.Close())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, version)
+		assert.Equal(t, "us-ashburn-1", string(body), version)
+	}
+	assert.Equal(t, []string{"/opc/v2/instance/region", "/opc/v2/instance/region"}, paths)
+	assert.Equal(t, []string{"Bearer Oracle", "Bearer Oracle"}, tokens)
+
+	// other hosts are left alone
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+		assert.Equal(t, "/opc/v1/instance/region", r.URL.Path)
+	}))
+	defer other.Close()
+	req, err := http.NewRequest(http.MethodGet, other.URL+"/opc/v1/instance/region", nil)
+	require.NoError(t, err)
+	resp, err := dispatcher.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	// a disabled metadata service gives a clear error
+	server.Close()
+	req, err = http.NewRequest(http.MethodGet, "http://"+imdsHost+"/opc/v2/instance/region", nil)
+	require.NoError(t, err)
+	_, err = dispatcher.Do(req)
+	assert.ErrorContains(t, err, "instance metadata service isn't reachable")
+}
+
+func TestHTTPClientGlobalFlags(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.Header().Set("Content-Length", "5")
+		w.Header().Set("last-modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+	}))
+	defer server.Close()
+
+	fshttp.ResetTransport()
+	defer fshttp.ResetTransport()
+	ci := fs.GetConfig(context.Background())
+	oldLogLevel, oldDump, oldUserAgent := ci.LogLevel, ci.Dump, ci.UserAgent
+	ci.LogLevel, ci.Dump, ci.UserAgent = fs.LogLevelDebug, fs.DumpHeaders, "rclone-test-agent"
+	var logs bytes.Buffer
+	oldLogPrint := fs.LogPrint
+	fs.LogPrint = func(level fs.LogLevel, text string) {
+		logs.WriteString(text + "\n")
+	}
+	defer func() {
+		ci.LogLevel, ci.Dump, ci.UserAgent = oldLogLevel, oldDump, oldUserAgent
+		fs.LogPrint = oldLogPrint
+	}()
+
+	client, err := newObjectStorageClient(context.Background(), &Options{Provider: noAuth, Namespace: "testns", Region: "us-ashburn-1"})
+	require.NoError(t, err)
+	client.Host = server.URL
+	_, err = client.HeadObject(context.Background(), objectstorage.HeadObjectRequest{
+		NamespaceName: common.String("testns"),
+		BucketName:    common.String("bucket"),
+		ObjectName:    common.String("file.txt"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "rclone-test-agent", userAgent)
+	assert.Contains(t, logs.String(), "HTTP REQUEST")
+	assert.Contains(t, logs.String(), "HEAD /n/testns/b/bucket/o/file.txt")
+}
+
+func TestGetEndpoint(t *testing.T) {
+	for _, test := range []struct {
+		endpoint string
+		want     string
+	}{
+		{"", ""},
+		{"https://objectstorage.us-ashburn-1.oraclecloud.com", "https://objectstorage.us-ashburn-1.oraclecloud.com"},
+		{"testns.objectstorage.us-ashburn-1.oci.customer-oci.com/", "https://testns.objectstorage.us-ashburn-1.oci.customer-oci.com"},
+		{"http://localhost:8080", "http://localhost:8080"},
+	} {
+		assert.Equal(t, test.want, getEndpoint(&Options{Endpoint: test.endpoint}), test.endpoint)
+	}
+}
+
+func TestRegionEndpoint(t *testing.T) {
+	for _, test := range []struct {
+		region string
+		want   string
+	}{
+		{"", ""},
+		{"us-ashburn-1", "https://objectstorage.us-ashburn-1.oraclecloud.com"},
+		{"eu-frankfurt-1", "https://objectstorage.eu-frankfurt-1.oraclecloud.com"},
+		{"iad", "https://objectstorage.us-ashburn-1.oraclecloud.com"},
+		{"FRA", "https://objectstorage.eu-frankfurt-1.oraclecloud.com"},
+		{"us-langley-1", "https://objectstorage.us-langley-1.oraclegovcloud.com"},
+		{"us-gov-ashburn-1", "https://objectstorage.us-gov-ashburn-1.oraclegovcloud.com"},
+		{"uk-gov-london-1", "https://objectstorage.uk-gov-london-1.oraclegovcloud.uk"},
+		// unknown regions get their realm from the prefix
+		{"us-gov-newregion-1", "https://objectstorage.us-gov-newregion-1.oraclegovcloud.com"},
+		{"uk-gov-newregion-1", "https://objectstorage.uk-gov-newregion-1.oraclegovcloud.uk"},
+		{"xx-newregion-1", "https://objectstorage.xx-newregion-1.oraclecloud.com"},
+	} {
+		assert.Equal(t, test.want, regionEndpoint(test.region), test.region)
+	}
+
+	// the endpoint wins over the region
+	assert.Equal(t, "https://example.com", getEndpoint(&Options{Region: "us-gov-ashburn-1", Endpoint: "example.com"}))
+	assert.Equal(t, "https://objectstorage.uk-gov-london-1.oraclegovcloud.uk", getEndpoint(&Options{Region: "uk-gov-london-1"}))
+}
+
+func TestCheckEndpointRegion(t *testing.T) {
+	for _, test := range []struct {
+		endpoint string
+		region   string
+		wantErr  string
+	}{
+		{"https://objectstorage.us-ashburn-1.oraclecloud.com", "us-ashburn-1", ""},
+		{"https://objectstorage.us-ashburn-1.oraclecloud.com", "iad", ""},
+		{"https://testns.objectstorage.eu-frankfurt-1.oci.customer-oci.com", "eu-frankfurt-1", ""},
+		{"https://objectstorage.uk-gov-london-1.oraclegovcloud.uk", "uk-gov-london-1", ""},
+		{"https://example.com", "us-ashburn-1", ""},
+		{"https://objectstorage.us-ashburn-1.oraclecloud.com", "", ""},
+		{"https://objectstorage.us-ashburn-1.oraclecloud.com", "eu-frankfurt-1",
+			`endpoint "https://objectstorage.us-ashburn-1.oraclecloud.com" is in region "us-ashburn-1" but region is "eu-frankfurt-1"`},
+		{"https://testns.objectstorage.eu-frankfurt-1.oci.customer-oci.com", "FRA", ""},
+		{"https://testns.objectstorage.eu-frankfurt-1.oci.customer-oci.com", "iad",
+			`endpoint "https://testns.objectstorage.eu-frankfurt-1.oci.customer-oci.com" is in region "eu-frankfurt-1" but region is "us-ashburn-1"`},
+	} {
+		err := checkEndpointRegion(test.endpoint, test.region)
+		if test.wantErr == "" {
+			assert.NoError(t, err, test.endpoint, test.region)
+		} else {
+			assert.ErrorContains(t, err, test.wantErr)
+		}
+	}
+
+	// NewFs checks unless no_check_bucket is set
+	ctx := context.Background()
+	regInfo, err := fs.Find("oracleobjectstorage")
+	require.NoError(t, err)
+	m := configmap.Simple{"provider": noAuth, "namespace": "testns", "region": "eu-frankfurt-1", "endpoint": "objectstorage.us-ashburn-1.oraclecloud.com"}
+	_, err = NewFs(ctx, "TestOOS", "bucket", fs.ConfigMap(regInfo, "TestOOS", m))
+	assert.ErrorContains(t, err, `is in region "us-ashburn-1" but region is "eu-frankfurt-1"`)
+	m["no_check_bucket"] = "true"
+	_, err = NewFs(ctx, "TestOOS", "bucket", fs.ConfigMap(regInfo, "TestOOS", m))
+	assert.NoError(t, err)
+}
+
+func TestRealmSpecificEndpoint(t *testing.T) {
+	t.Setenv(realmSpecificEnvVar, "")
+	opt := &Options{Namespace: "testns", Region: "iad", RealmSpecificEndpoint: true}
+	assert.Equal(t, "https://testns.objectstorage.us-ashburn-1.oci.customer-oci.com", getEndpoint(opt))
+	// the endpoint wins
+	opt.Endpoint = "example.com"
+	assert.Equal(t, "https://example.com", getEndpoint(opt))
+	// realms without a known dedicated endpoint use the default one
+	opt = &Options{Namespace: "testns", Region: "us-gov-ashburn-1", RealmSpecificEndpoint: true}
+	assert.Equal(t, "https://objectstorage.us-gov-ashburn-1.oraclegovcloud.com", getEndpoint(opt))
+
+	// every request is sent to the dedicated endpoint when enabled in
+	// the environment
+	t.Setenv(realmSpecificEnvVar, "true")
+	regInfo, err := fs.Find("oracleobjectstorage")
+	require.NoError(t, err)
+	f, err := NewFs(context.Background(), "TestOOS", "bucket", fs.ConfigMap(regInfo, "TestOOS", configmap.Simple{
+		"provider":  noAuth,
+		"namespace": "testns",
+		"region":    "eu-frankfurt-1",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "https://testns.objectstorage.eu-frankfurt-1.oci.customer-oci.com", f.(*Fs).srv.Host)
+}
+
+func TestSetOptionsFromEnv(t *testing.T) {
+	t.Setenv("OCI_NAMESPACE", "envns")
+	t.Setenv("OCI_COMPARTMENT", "")
+	t.Setenv("OCI_COMPARTMENT_ID", "ocid1.compartment.oc1..env")
+	t.Setenv("OCI_REGION", "eu-frankfurt-1")
+	t.Setenv("OCI_RESOURCE_PRINCIPAL_REGION", "us-phoenix-1")
+	t.Setenv("OCI_ENDPOINT", "https://example.com")
+
+	opt := &Options{Provider: environmentAuth}
+	setOptionsFromEnv(opt)
+	assert.Equal(t, "envns", opt.Namespace)
+	assert.Equal(t, "ocid1.compartment.oc1..env", opt.Compartment)
+	assert.Equal(t, "eu-frankfurt-1", opt.Region)
+	assert.Equal(t, "https://example.com", opt.Endpoint)
+
+	// config wins over the environment
+	opt = &Options{Provider: environmentAuth, Namespace: "confns", Region: "us-ashburn-1"}
+	setOptionsFromEnv(opt)
+	assert.Equal(t, "confns", opt.Namespace)
+	assert.Equal(t, "us-ashburn-1", opt.Region)
+	assert.Equal(t, "ocid1.compartment.oc1..env", opt.Compartment)
+
+	// only env_auth reads the environment
+	opt = &Options{Provider: userPrincipal}
+	setOptionsFromEnv(opt)
+	assert.Equal(t, &Options{Provider: userPrincipal}, opt)
+}
+
+func TestCheckNamespace(t *testing.T) {
+	for _, namespace := range []string{"axaxnpcrorw5", "MyTenancy1"} {
+		assert.NoError(t, checkNamespace(namespace), namespace)
+	}
+	for _, namespace := range []string{"", "my namespace", "ns/1", "axaxnpcrorw5 "} {
+		assert.Error(t, checkNamespace(namespace), namespace)
+	}
+}
+
+func TestCheckCompartment(t *testing.T) {
+	for _, compartment := range []string{
+		"",
+		"ocid1.compartment.oc1..aaaaaaaa5ot2bcp7yu3ml4dbq2ahnuk5j3cy4dul4u3n6dkqrwbcsgw4mbcq",
+		"ocid1.tenancy.oc1..aaaaaaaaba3pv6wkcr4jqae5f15p2b2m2yt2j6rx32uzr4h25vqstifsfdsq",
+		"ocid1.compartment.oc2.us-langley-1.aaaaaaaa5ot2bcp7yu3ml4dbq2ahnuk5",
+	} {
+		assert.NoError(t, checkCompartment(compartment), compartment)
+	}
+	for _, compartment := range []string{
+		"aaaaaaaa5ot2bcp7yu3ml4dbq2ahnuk5j3cy4dul4u3n6dkqrwbcsgw4mbcq",
+		"ocid1.bucket.oc1..aaaaaaaa5ot2bcp7yu3ml4d",
+		"ocid1.compartment.oc1..",
+		" ocid1.compartment.oc1..aaaaaaaa5ot2bcp7yu3ml4d",
+		"ocid.compartment.oc1..aaaaaaaa5ot2bcp7yu3ml4d",
+	} {
+		assert.Error(t, checkCompartment(compartment), compartment)
+	}
+}
+
+func TestNewFsValidatesConfig(t *testing.T) {
+	ctx := context.Background()
+	regInfo, err := fs.Find("oracleobjectstorage")
+	require.NoError(t, err)
+	newFs := func(m configmap.Simple) error {
+		_, err := NewFs(ctx, "TestOOS", "bucket", fs.ConfigMap(regInfo, "TestOOS", m))
+		return err
+	}
+	err = newFs(configmap.Simple{"provider": noAuth, "namespace": "test ns", "region": "us-ashburn-1"})
+	assert.ErrorContains(t, err, `not a valid namespace "test ns"`)
+	err = newFs(configmap.Simple{"provider": userPrincipal, "namespace": "testns", "region": "us-ashburn-1", "compartment": "ocid1.compartmnet.oc1..abc"})
+	assert.ErrorContains(t, err, `not a valid compartment "ocid1.compartmnet.oc1..abc"`)
+
+	// the compartment isn't used by no_auth
+	err = newFs(configmap.Simple{"provider": noAuth, "namespace": "testns", "region": "us-ashburn-1", "compartment": "junk"})
+	assert.NoError(t, err)
+
+	err = newFs(configmap.Simple{"provider": noAuth, "namespace": "testns", "region": "us-ashburn-1", "list_chunk": "1001"})
+	assert.ErrorContains(t, err, "list_chunk 1001 must be between 1 and 1000")
+	err = newFs(configmap.Simple{"provider": noAuth, "namespace": "testns", "region": "us-ashburn-1", "list_fields": "md5,tier"})
+	assert.ErrorContains(t, err, `not a valid list field "tier"`)
+	err = newFs(configmap.Simple{"provider": noAuth, "namespace": "testns", "region": "us-ashburn-1", "upload_concurrency": "lots"})
+	assert.ErrorContains(t, err, `upload_concurrency must be a number or auto, got "lots"`)
+	err = newFs(configmap.Simple{"provider": noAuth, "namespace": "testns", "region": "us-ashburn-1", "chunk_size": "4M"})
+	assert.ErrorContains(t, err, "chunk_size: 4Mi is less than 5Mi")
+	err = newFs(configmap.Simple{"provider": noAuth, "namespace": "testns", "region": "us-ashburn-1", "chunk_size": "51G"})
+	assert.ErrorContains(t, err, "chunk_size: 51Gi is greater than 50Gi")
+	err = newFs(configmap.Simple{"provider": noAuth, "namespace": "testns", "region": "us-ashburn-1", "chunk_size": "50G"})
+	assert.NoError(t, err)
+}
+
+func TestCustomEndpoint(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/o":
+			_, _ = w.Write([]byte(`{"objects":[{"name":"file.txt","size":5,"timeModified":"2006-01-02T15:04:05Z"}]}`))
+		case r.Method == http.MethodPut:
+		case r.Method == http.MethodHead:
+			w.Header().Set("Content-Length", "5")
+			w.Header().Set("last-modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	f, ts := newTestFs(t, "bucket", handler, configmap.Simple{"no_check_bucket": "true"})
+	// the region based host is replaced by the endpoint
+	assert.NotContains(t, f.srv.Host, "oraclecloud.com")
+	putTestObject(t, f, "file.txt", []byte("hello"))
+	entries, err := f.List(context.Background(), "")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	// all the requests were received by the endpoint
+	assert.Equal(t, 3, len(ts.requests))
+}
+
+// serveObject returns a handler serving content for every object
+// honoring Range requests and recording the ranges asked for
+func serveObject(content []byte, encoding string, ranges *[]string) http.HandlerFunc {
+	var mu sync.Mutex
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			mu.Lock()
+			*ranges = append(*ranges, r.Header.Get("Range"))
+			mu.Unlock()
+		}
+		if encoding != "" {
+			w.Header().Set("Content-Encoding", encoding)
+		}
+		http.ServeContent(w, r, "", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC), bytes.NewReader(content))
+	}
+}
+
+func TestDownloadIgnoreChecksum(t *testing.T) {
+	content := []byte(random.String(100))
+	wrong := md5.Sum([]byte("something else"))
+	var ranges []string
+	serve := serveObject(content, "", &ranges)
+	f, _ := newTestFs(t, "bucket", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(wrong[:]))
+		serve(w, r)
+	}, nil)
+	dst, err := fs.NewFs(context.Background(), ":memory:"+t.Name())
+	require.NoError(t, err)
+	for _, ignore := range []bool{false, true} {
+		t.Run(fmt.Sprint(ignore), func(t *testing.T) {
+			ctx, ci := fs.AddConfig(context.Background())
+			ci.IgnoreChecksum = ignore
+			o, err := f.NewObject(ctx, "file.bin")
+			require.NoError(t, err)
+			_, err = operations.Copy(ctx, dst, nil, "file.bin", o)
+			if !ignore {
+				// rclone checks the MD5 of what was downloaded
+				assert.ErrorContains(t, err, "corrupted on transfer: md5 hash differ")
+				return
+			}
+			// --ignore-checksum skips the MD5 of the download
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestConditionalDownload(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("hello")
+	modTime := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	var (
+		ranges []string
+		sent   http.Header // headers of the last GET
+	)
+	serve := serveObject(content, "", &ranges)
+	f, _ := newTestFs(t, "bucket", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			sent = r.Header.Clone()
+		}
+		// the conditions are checked by the server which answers
+		// 304 Not Modified without a body
+		w.Header().Set("ETag", `"etag-1"`)
+		serve(w, r)
+	}, nil)
+	o, err := f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	for _, test := range []struct {
+		key, value  string
+		notModified bool
+	}{
+		{key: "If-None-Match", value: `"etag-1"`, notModified: true},
+		{key: "If-None-Match", value: `"etag-2"`},
+		{key: "If-Modified-Since", value: modTime.Format(http.TimeFormat), notModified: true},
+		{key: "If-Modified-Since", value: modTime.Add(time.Hour).Format(http.TimeFormat), notModified: true},
+		{key: "If-Modified-Since", value: modTime.Add(-time.Second).Format(http.TimeFormat)},
+	} {
+		t.Run(test.key+"="+test.value, func(t *testing.T) {
+			in, err := o.Open(ctx, &fs.HTTPOption{Key: test.key, Value: test.value})
+			assert.Equal(t, test.value, sent.Get(test.key))
+			if test.notModified {
+				assert.True(t, errors.Is(err, ErrorNotModified))
+				assert.Nil(t, in)
+				return
+			}
+			require.NoError(t, err)
+			got, err := io.ReadAll(in)
+			require.NoError(t, err)
+			require.NoError(t, in.Close())
+			assert.Equal(t, content, got)
+		})
+	}
+}
+
+func TestConcurrentDownload(t *testing.T) {
+	ctx := context.Background()
+	content := []byte(random.String(100))
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	_, err := zw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	for _, encoding := range []string{"", "gzip"} {
+		t.Run(encoding, func(t *testing.T) {
+			var ranges []string
+			stored := content
+			if encoding == "gzip" {
+				stored = gzipped.Bytes()
+			}
+			f, _ := newTestFs(t, "bucket", serveObject(stored, encoding, &ranges), configmap.Simple{
+				"download_concurrency": "3",
+			})
+			f.opt.ChunkSize = 30
+			o, err := f.NewObject(ctx, "file.bin")
+			require.NoError(t, err)
+
+			in, err := o.Open(ctx)
+			require.NoError(t, err)
+			got, err := io.ReadAll(in)
+			require.NoError(t, err)
+			require.NoError(t, in.Close())
+			assert.Equal(t, content, got)
+			sort.Strings(ranges)
+			if encoding != "" {
+				// read in one request and decompressed by the transport
+				assert.Equal(t, []string{""}, ranges)
+				return
+			}
+			assert.Equal(t, []string{"bytes=0-29", "bytes=30-59", "bytes=60-89", "bytes=90-99"}, ranges)
+
+			// a ranged read returns the requested bytes only
+			ranges = nil
+			in, err = o.Open(ctx, &fs.RangeOption{Start: 10, End: 19})
+			require.NoError(t, err)
+			got, err = io.ReadAll(in)
+			require.NoError(t, err)
+			require.NoError(t, in.Close())
+			assert.Equal(t, content[10:20], got)
+			assert.Equal(t, []string{"bytes=10-19"}, ranges)
+		})
+	}
+}
+
+func TestMightGzip(t *testing.T) {
+	ctx := context.Background()
+	content := []byte(random.String(100))
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	_, err := zw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	for _, mightGzip := range []bool{false, true} {
+		t.Run(fmt.Sprint(mightGzip), func(t *testing.T) {
+			// the object is stored as is but sent gzipped
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("last-modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+				w.Header().Set("Content-Length", fmt.Sprint(gzipped.Len()))
+				if r.Method == http.MethodGet {
+					w.Header().Set("Content-Encoding", "gzip")
+					_, _ = w.Write(gzipped.Bytes())
+				}
+			}
+			f, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+				"might_gzip": fmt.Sprint(mightGzip),
+			})
+			o, err := f.NewObject(ctx, "file.bin")
+			require.NoError(t, err)
+			assert.Equal(t, int64(gzipped.Len()), o.Size())
+
+			in, err := o.Open(ctx)
+			require.NoError(t, err)
+			got, err := io.ReadAll(in)
+			require.NoError(t, err)
+			require.NoError(t, in.Close())
+			assert.Equal(t, content, got)
+			if !mightGzip {
+				// the size no longer matches what was read
+				assert.Equal(t, int64(gzipped.Len()), o.Size())
+				return
+			}
+			// the size and hash of what was read aren't known so
+			// aren't checked
+			assert.Equal(t, int64(-1), o.Size())
+			sum, err := o.Hash(ctx, hash.MD5)
+			require.NoError(t, err)
+			assert.Equal(t, "", sum)
+		})
+	}
+}
+
+func TestWarnRetrievalCost(t *testing.T) {
+	ctx := context.Background()
+	var (
+		mu   sync.Mutex
+		logs []string
+	)
+	oldLogPrint := fs.LogPrint
+	fs.LogPrint = func(level fs.LogLevel, text string) {
+		if strings.Contains(text, "retrieval costs") {
+			mu.Lock()
+			logs = append(logs, text)
+			mu.Unlock()
+		}
+	}
+	defer func() {
+		fs.LogPrint = oldLogPrint
+	}()
+	tiers := map[string]string{
+		"standard.txt": "Standard",
+		"ia1.txt":      "InfrequentAccess",
+		"ia2.txt":      "InfrequentAccess",
+	}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("storage-tier", tiers[path.Base(r.URL.Path)])
+		w.Header().Set("last-modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("Content-Length", "5")
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte("hello"))
+		}
+	}
+	read := func(f *Fs, remote string) {
+		o, err := f.NewObject(ctx, remote)
+		require.NoError(t, err)
+		in, err := o.Open(ctx)
+		require.NoError(t, err)
+		_, err = io.ReadAll(in)
+		require.NoError(t, err)
+		require.NoError(t, in.Close())
+	}
+
+	f, _ := newTestFs(t, "bucket", handler, configmap.Simple{"warn_retrieval_cost": "true"})
+	read(f, "standard.txt")
+	assert.Empty(t, logs)
+	read(f, "ia1.txt")
+	read(f, "ia2.txt")
+	assert.Equal(t, []string{"ia1.txt: Reading objects in the infrequentaccess storage tier incurs retrieval costs"}, logs)
+
+	logs = nil
+	f, _ = newTestFs(t, "bucket", handler, nil)
+	read(f, "ia1.txt")
+	assert.Empty(t, logs)
+}
+
+func TestOpenRange(t *testing.T) {
+	ctx := context.Background()
+	content := []byte(random.String(100))
+	var ranges []string
+	f, _ := newTestFs(t, "bucket", serveObject(content, "", &ranges), nil)
+	o, err := f.NewObject(ctx, "file.bin")
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		name    string
+		option  fs.OpenOption
+		want    []byte
+		request []string
+	}{
+		{"Start", &fs.RangeOption{Start: 0, End: 9}, content[:10], []string{"bytes=0-9"}},
+		{"Middle", &fs.RangeOption{Start: 40, End: 59}, content[40:60], []string{"bytes=40-59"}},
+		{"Seek", &fs.SeekOption{Offset: 90}, content[90:], []string{"bytes=90-99"}},
+		{"Suffix", &fs.RangeOption{Start: -1, End: 5}, content[95:], []string{"bytes=95-99"}},
+		{"EndPastEOF", &fs.RangeOption{Start: 95, End: 200}, content[95:], []string{"bytes=95-99"}},
+		{"StartPastEOF", &fs.RangeOption{Start: 150, End: -1}, []byte{}, nil},
+		{"SeekToEOF", &fs.SeekOption{Offset: 100}, []byte{}, nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ranges = nil
+			in, err := o.Open(ctx, test.option)
+			require.NoError(t, err)
+			got, err := io.ReadAll(in)
+			require.NoError(t, err)
+			require.NoError(t, in.Close())
+			assert.Equal(t, test.want, got)
+			assert.Equal(t, test.request, ranges)
+			// the size is still that of the whole object
+			assert.Equal(t, int64(len(content)), o.Size())
+		})
+	}
+}
+
+func TestPacerJitter(t *testing.T) {
+	c := newJitterCalculator(pacer.MinSleep(minSleep), pacer.MaxSleep(maxSleep), pacer.DecayConstant(decayConstant))
+	state := pacer.State{}
+	state.SleepTime = c.Calculate(state)
+	assert.Equal(t, minSleep, state.SleepTime)
+
+	// each retry doubles the backoff and sleeps for between half and
+	// all of it
+	backoff := minSleep
+	sleeps := map[time.Duration]bool{}
+	for retries := 1; retries <= 20; retries++ {
+		state.ConsecutiveRetries = retries
+		state.SleepTime = c.Calculate(state)
+		backoff *= 2
+		if backoff > maxSleep {
+			backoff = maxSleep
+		}
+		assert.GreaterOrEqual(t, state.SleepTime, backoff/2, "retry %d", retries)
+		assert.LessOrEqual(t, state.SleepTime, backoff, "retry %d", retries)
+		if backoff == maxSleep {
+			sleeps[state.SleepTime] = true
+		}
+	}
+	assert.Greater(t, len(sleeps), 1, "sleeps at max backoff should differ")
+
+	// Retry-After is used as it is
+	state.LastError = pacer.RetryAfterError(errors.New("throttled"), 3*time.Second)
+	assert.Equal(t, 3*time.Second, c.Calculate(state))
+
+	// without retries the sleep decays without jitter
+	state.LastError = nil
+	state.ConsecutiveRetries = 0
+	assert.Equal(t, 1500*time.Millisecond, c.Calculate(state))
+}
+
+func TestWaitForStateBackoff(t *testing.T) {
+	var times []time.Time
+	conf := &StateChangeConf{
+		Pending: []string{"pending"},
+		Target:  []string{"done"},
+		Refresh: func() (interface{}, string, error) {
+			times = append(times, time.Now())
+			if len(times) < 5 {
+				return "result", "pending", nil
+			}
+			return "result", "done", nil
+		},
+		Timeout:    time.Minute,
+		MaxTimeout: 300 * time.Millisecond,
+	}
+	result, err := conf.WaitForStateContext(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Equal(t, "result", result)
+	require.Len(t, times, 5)
+	// the interval doubles from 200ms until capped by MaxTimeout
+	for i, want := range []time.Duration{200, 300, 300, 300} {
+		want *= time.Millisecond
+		interval := times[i+1].Sub(times[i])
+		assert.GreaterOrEqual(t, interval, want-10*time.Millisecond, "interval %d", i)
+		assert.Less(t, interval, want+200*time.Millisecond, "interval %d", i)
+	}
+}
+
+func TestCopyWaitForWorkRequest(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		status  string
+		wantErr string
+	}{
+		{"COMPLETED", ""},
+		{"FAILED", "work request failed, workId: wr1, entity: file.txt. Message: copy went wrong"},
+		{"CANCELED", "work request was canceled"},
+		{"IN_PROGRESS", "timed out after 100ms waiting for work request to copy"},
+	} {
+		t.Run(test.status, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/workRequests/wr1":
+					_, _ = fmt.Fprintf(w, `{"id":"wr1","status":%q,"percentComplete":50}`, test.status)
+				case "/workRequests/wr1/errors":
+					_, _ = w.Write([]byte(`[{"code":"Failed","message":"copy went wrong"}]`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}
+			f, _ := newTestFs(t, "bucket", handler, nil)
+			oldGracePeriod := refreshGracePeriod
+			refreshGracePeriod = 0
+			defer func() { refreshGracePeriod = oldGracePeriod }()
+			err := copyObjectWaitForWorkRequest(ctx, common.String("wr1"), "file.txt", 100*time.Millisecond, time.Second, f.srv)
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCopyLogsWorkRequest(t *testing.T) {
+	ctx := context.Background()
+	ci := fs.GetConfig(ctx)
+	oldLogLevel := ci.LogLevel
+	ci.LogLevel = fs.LogLevelInfo
+	var (
+		mu   sync.Mutex
+		logs []string
+	)
+	oldLogPrint := fs.LogPrint
+	fs.LogPrint = func(level fs.LogLevel, text string) {
+		mu.Lock()
+		logs = append(logs, text)
+		mu.Unlock()
+	}
+	defer func() {
+		ci.LogLevel = oldLogLevel
+		fs.LogPrint = oldLogPrint
+	}()
+	store := newMemoryStore()
+	f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{"no_check_bucket": "true"})
+	o := putTestObject(t, f, "file.txt", []byte("hello"))
+	logs = nil
+	_, err := f.Copy(ctx, o, "copy.txt")
+	require.NoError(t, err)
+	assert.Contains(t, logs, "copy.txt: server-side copy started with work request wr-copy")
+	assert.Contains(t, logs, "copy.txt: server-side copy 100% complete, work request wr-copy")
+}
+
+func TestCopyConcurrency(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	var inFlight, maxInFlight int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/actions/copyObject") {
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+					break
+				}
+			}
+			// longer than the pacer waits between requests
+			time.Sleep(300 * time.Millisecond)
+		}
+		store.handler(w, r)
+	}
+	f, _ := newTestFs(t, "bucket", handler, configmap.Simple{"no_check_bucket": "true", "copy_concurrency": "3"})
+	o := putTestObject(t, f, "file.txt", []byte("hello"))
+	var wg sync.WaitGroup
+	for i := 0; i < 9; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := f.Copy(ctx, o, fmt.Sprintf("copy%d.txt", i))
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), maxInFlight)
+	assert.Len(t, store.objects, 10)
+
+	regInfo, err := fs.Find("oracleobjectstorage")
+	require.NoError(t, err)
+	_, err = NewFs(ctx, "TestOOS", "bucket", fs.ConfigMap(regInfo, "TestOOS", configmap.Simple{
+		"provider":         noAuth,
+		"namespace":        "testns",
+		"copy_concurrency": "0",
+	}))
+	assert.ErrorContains(t, err, "copy_concurrency 0 must be at least 1")
+}
+
+func TestRunID(t *testing.T) {
+	ctx := context.Background()
+	for _, forceMultipart := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Multipart=%v", forceMultipart), func(t *testing.T) {
+			store := newMemoryStore()
+			f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{
+				"no_check_bucket": "true",
+				"force_multipart": fmt.Sprint(forceMultipart),
+				"run_id":          "pipeline-42",
+			})
+			putTestObject(t, f, "file.txt", []byte("hello"))
+			assert.Equal(t, forceMultipart, store.partMD5["file.txt"] != "")
+			assert.Equal(t, "pipeline-42", store.meta["file.txt"].Get(ociMetaPrefix+metaRunID))
+
+			// it is read back with the metadata of the object
+			o, err := f.NewObject(ctx, "file.txt")
+			require.NoError(t, err)
+			assert.Equal(t, "pipeline-42", o.(*Object).meta[metaRunID])
+		})
+	}
+
+	// uploads aren't stamped without it
+	store := newMemoryStore()
+	f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{"no_check_bucket": "true"})
+	putTestObject(t, f, "file.txt", []byte("hello"))
+	assert.Empty(t, store.meta["file.txt"].Get(ociMetaPrefix+metaRunID))
+}
+
+func TestUploadCondition(t *testing.T) {
+	ctx := context.Background()
+	contents := []byte("hello")
+	src := func(remote string) fs.ObjectInfo {
+		return object.NewStaticObjectInfo(remote, time.Now(), int64(len(contents)), true, nil, nil)
+	}
+	for _, forceMultipart := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Multipart=%v", forceMultipart), func(t *testing.T) {
+			store := newMemoryStore()
+			newFs := func(condition string) *Fs {
+				f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{
+					"no_check_bucket":  "true",
+					"force_multipart":  fmt.Sprint(forceMultipart),
+					"upload_condition": condition,
+				})
+				return f
+			}
+
+			t.Run("CreateOnly", func(t *testing.T) {
+				f := newFs(uploadConditionCreateOnly)
+				o := putTestObject(t, f, "create.txt", contents)
+				_, err := f.Put(ctx, bytes.NewReader(contents), src("create.txt"))
+				assert.ErrorContains(t, err, "precondition failed: create.txt exists already")
+				assert.True(t, fserrors.IsNoRetryError(err))
+				err = o.Update(ctx, bytes.NewReader(contents), src("create.txt"))
+				assert.ErrorContains(t, err, "precondition failed")
+			})
+
+			t.Run("IfUnchanged", func(t *testing.T) {
+				f := newFs(uploadConditionIfUnchanged)
+				o := putTestObject(t, f, "file.txt", contents)
+				etag := o.(*Object).ETag()
+				require.NotEmpty(t, etag)
+
+				// an object which hasn't changed is replaced
+				require.NoError(t, o.Update(ctx, bytes.NewReader(contents), src("file.txt")))
+				assert.NotEqual(t, etag, o.(*Object).ETag())
+
+				// but not once another writer has changed it
+				putTestObject(t, newFs(""), "file.txt", []byte("other"))
+				err := o.Update(ctx, bytes.NewReader(contents), src("file.txt"))
+				assert.ErrorContains(t, err, "precondition failed: file.txt has changed since it was read with ETag")
+				assert.True(t, fserrors.IsNoRetryError(err))
+				assert.Equal(t, []byte("other"), store.objects["file.txt"])
+
+				// and new objects aren't uploaded if they exist
+				_, err = f.Put(ctx, bytes.NewReader(contents), src("file.txt"))
+				assert.ErrorContains(t, err, "precondition failed: file.txt exists already")
+			})
+		})
+	}
+
+	regInfo, err := fs.Find("oracleobjectstorage")
+	require.NoError(t, err)
+	_, err = NewFs(ctx, "TestOOS", "bucket", fs.ConfigMap(regInfo, "TestOOS", configmap.Simple{
+		"provider":         noAuth,
+		"namespace":        "testns",
+		"upload_condition": "sometimes",
+	}))
+	assert.ErrorContains(t, err, `upload_condition "sometimes" must be "create-only" or "if-unchanged"`)
+}
+
+func TestPutUserMetadata(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	stored := http.Header{}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			for k, v := range r.Header {
+				if strings.HasPrefix(strings.ToLower(k), ociMetaPrefix) {
+					stored[k] = v
+				}
+			}
+		case http.MethodHead:
+			for k, v := range stored {
+				w.Header()[k] = v
+			}
+			w.Header().Set("Content-Length", "5")
+		}
+	}
+	f, _ := newTestFs(t, "bucket", handler, configmap.Simple{"no_check_bucket": "true"})
+	src := object.NewStaticObjectInfo("file.txt", time.Now(), 5, true, nil, f)
+	o, err := f.Put(ctx, bytes.NewReader([]byte("hello")), src,
+		&fs.HTTPOption{Key: "opc-meta-cost-centre", Value: "1234"},
+		&fs.HTTPOption{Key: "Opc-Meta-Project", Value: "rclone"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "1234", stored.Get("opc-meta-cost-centre"))
+	assert.Equal(t, "rclone", stored.Get("opc-meta-project"))
+	assert.Empty(t, stored.Get("opc-meta-opc-meta-cost-centre"))
+	meta := o.(*Object).meta
+	assert.Equal(t, "1234", meta["cost-centre"])
+	assert.Equal(t, "rclone", meta["project"])
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	ctx, ci := fs.AddConfig(context.Background())
+	ci.Metadata = true
+	store := newMemoryStore()
+	f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{"no_check_bucket": "true"})
+	modTime := time.Date(2021, 2, 3, 4, 5, 6, 789000000, time.UTC)
+	src := object.NewStaticObjectInfo("file.txt", time.Now(), 5, true, nil, f)
+	_, err := f.Put(ctx, strings.NewReader("hello"), src, fs.MetadataOption{
+		"mode":  "100640",
+		"uid":   "1000",
+		"gid":   "1000",
+		"mtime": modTime.Format(time.RFC3339Nano),
+		"btime": "2020-01-02T03:04:05Z",
+		"tier":  "Standard",
+	})
+	require.NoError(t, err)
+	stored := store.meta["file.txt"]
+	assert.Equal(t, "100640", stored.Get("opc-meta-mode"))
+	assert.Equal(t, "1000", stored.Get("opc-meta-uid"))
+	assert.Equal(t, "1000", stored.Get("opc-meta-gid"))
+	assert.Equal(t, "2020-01-02T03:04:05Z", stored.Get("opc-meta-btime"))
+	assert.Equal(t, "1612325106.789", stored.Get("opc-meta-mtime"))
+	assert.Empty(t, stored.Get("opc-meta-tier"))
+
+	// the metadata is read back in rclone's form
+	obj, err := f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	metadata, err := obj.(fs.Metadataer).Metadata(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "100640", metadata["mode"])
+	assert.Equal(t, "1000", metadata["uid"])
+	assert.Equal(t, "1000", metadata["gid"])
+	assert.Equal(t, "2020-01-02T03:04:05Z", metadata["btime"])
+	assert.Equal(t, modTime.Format(time.RFC3339Nano), metadata["mtime"])
+	assert.NotContains(t, metadata, "md5chksum")
+	assert.True(t, obj.ModTime(ctx).Equal(modTime))
+
+	// and written again when it is the source of an upload
+	_, err = f.Put(ctx, strings.NewReader("hello"), object.NewStaticObjectInfo("copy.txt", time.Now(), 5, true, nil, f).WithMetadata(metadata))
+	require.NoError(t, err)
+	assert.Equal(t, stored, store.meta["copy.txt"])
+}
+
+func TestRetentionUntil(t *testing.T) {
+	modTime := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	rule := func(amount int64, unit objectstorage.DurationTimeUnitEnum) objectstorage.RetentionRuleSummary {
+		return objectstorage.RetentionRuleSummary{
+			Duration: &objectstorage.Duration{TimeAmount: common.Int64(amount), TimeUnit: unit},
+		}
+	}
+	until, indefinite := retentionUntil([]objectstorage.RetentionRuleSummary{
+		rule(30, objectstorage.DurationTimeUnitDays),
+		rule(1, objectstorage.DurationTimeUnitYears),
+	}, modTime)
+	assert.False(t, indefinite)
+	assert.Equal(t, time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC), until)
+	_, indefinite = retentionUntil([]objectstorage.RetentionRuleSummary{
+		rule(30, objectstorage.DurationTimeUnitDays),
+		{},
+	}, modTime)
+	assert.True(t, indefinite)
+}
+
+func TestLegalHold(t *testing.T) {
+	ctx := context.Background()
+	rules := ""
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/n/testns/b/bucket/o/file.txt":
+			w.Header().Set("Content-Length", "5")
+			w.Header().Set("last-modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/retentionRules":
+			_, _ = w.Write([]byte(`{"items":[` + rules + `]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	f, ts := newTestFs(t, "bucket", handler, nil)
+
+	// no rules so no hold
+	got, err := f.Command(ctx, "legal-hold", []string{"file.txt"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &legalHoldState{Bucket: "bucket", Object: "file.txt"}, got)
+
+	// a rule without a duration holds everything
+	rules = `{"id":"r1","displayName":"keep","duration":{"timeAmount":1,"timeUnit":"DAYS"}},{"id":"r2","displayName":"litigation"}`
+	got, err = f.Command(ctx, "legal-hold", []string{"file.txt"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &legalHoldState{Bucket: "bucket", Object: "file.txt", LegalHold: true, HoldRules: []string{"litigation"}}, got)
+
+	// a rule with a duration retains it until then
+	rules = `{"id":"r1","displayName":"keep","duration":{"timeAmount":100,"timeUnit":"YEARS"}}`
+	got, err = f.Command(ctx, "legal-hold", []string{"file.txt"}, nil)
+	require.NoError(t, err)
+	state := got.(*legalHoldState)
+	assert.False(t, state.LegalHold)
+	require.NotNil(t, state.RetainedUntil)
+	assert.Equal(t, time.Date(2106, 1, 2, 15, 4, 5, 0, time.UTC), state.RetainedUntil.UTC())
+
+	// holds can't be set or cleared per object
+	requests := len(ts.requests)
+	for _, opt := range []string{"on", "off"} {
+		_, err = f.Command(ctx, "legal-hold", []string{"file.txt"}, map[string]string{opt: ""})
+		assert.ErrorIs(t, err, fs.ErrorNotImplemented)
+		assert.ErrorContains(t, err, "add a retention rule without a duration to the bucket")
+	}
+	assert.Equal(t, requests, len(ts.requests))
+
+	_, err = f.Command(ctx, "legal-hold", nil, nil)
+	assert.ErrorContains(t, err, "legal-hold needs an object")
+	_, err = f.Command(ctx, "legal-hold", []string{"missing.txt"}, nil)
+	assert.ErrorIs(t, err, fs.ErrorObjectNotFound)
+}
+
+func TestRetentionUntilMetadata(t *testing.T) {
+	ctx, ci := fs.AddConfig(context.Background())
+	ci.Metadata = true
+	modTime := time.Now().UTC().Truncate(time.Second)
+	rules := `{"id":"r1","displayName":"keep","duration":{"timeAmount":30,"timeUnit":"DAYS"}}`
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/o":
+			_, _ = w.Write([]byte(`{"objects":[{"name":"a.txt","size":5,"timeModified":"2006-01-02T15:04:05Z"},{"name":"b.txt","size":5,"timeModified":"2006-01-02T15:04:05Z"}]}`))
+		case r.Method == http.MethodHead:
+			w.Header().Set("Content-Length", "5")
+			w.Header().Set("last-modified", modTime.Format(http.TimeFormat))
+		case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/retentionRules":
+			_, _ = w.Write([]byte(`{"items":[` + rules + `]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	f, ts := newTestFs(t, "bucket", handler, configmap.Simple{"no_check_bucket": "true"})
+	lsjson := func() map[string]string {
+		got := map[string]string{}
+		err := operations.ListJSON(ctx, f, "", &operations.ListJSONOpt{Metadata: true}, func(item *operations.ListJSONItem) error {
+			got[item.Path] = item.Metadata[metaRetentionUntil]
+			return nil
+		})
+		require.NoError(t, err)
+		return got
+	}
+	want := modTime.AddDate(0, 0, 30).Format(time.RFC3339)
+	assert.Equal(t, map[string]string{"a.txt": want, "b.txt": want}, lsjson())
+	// the rules are only read once
+	assert.Equal(t, []string{"GET /n/testns/b/bucket/o", "HEAD /n/testns/b/bucket/o/a.txt", "GET /n/testns/b/bucket/retentionRules", "HEAD /n/testns/b/bucket/o/b.txt"}, ts.requests)
+
+	// a rule without a duration retains them indefinitely
+	f.retention.rules = nil
+	rules = `{"id":"r2","displayName":"litigation"}`
+	assert.Equal(t, map[string]string{"a.txt": "indefinite", "b.txt": "indefinite"}, lsjson())
+
+	// and nothing is shown once retention has finished
+	f.retention.rules = nil
+	rules = `{"id":"r1","displayName":"keep","duration":{"timeAmount":0,"timeUnit":"DAYS"}}`
+	assert.Equal(t, map[string]string{"a.txt": "", "b.txt": ""}, lsjson())
+}
+
+func TestRetentionError(t *testing.T) {
+	ctx := context.Background()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodDelete:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"code":"Conflict","message":"Object 'file.txt' is protected by a retention rule"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/retentionRules":
+			_, _ = w.Write([]byte(`{"items":[{"id":"r1","displayName":"keep","etag":"e1",` +
+				`"timeCreated":"2029-01-01T00:00:00Z","timeModified":"2029-01-01T00:00:00Z",` +
+				`"duration":{"timeAmount":2,"timeUnit":"YEARS"}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	f, _ := newTestFs(t, "bucket", handler, nil)
+	o := &Object{
+		fs:           f,
+		remote:       "file.txt",
+		lastModified: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	err := o.Remove(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "object is under retention until 2032-01-01T00:00:00Z")
+	var serviceErr common.ServiceError
+	require.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, http.StatusConflict, serviceErr.GetHTTPStatusCode())
+
+	rules, err := f.Command(ctx, "retention", nil, nil)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, "keep", *rules.([]objectstorage.RetentionRuleSummary)[0].DisplayName)
+}
+
+func TestCopyAcrossRegions(t *testing.T) {
+	ctx := context.Background()
+	var details objectstorage.CopyObjectDetails
+	srcHandler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/n/testns/b/bucket/actions/copyObject":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&details))
+			w.Header().Set("opc-work-request-id", "wr1")
+		case r.URL.Path == "/workRequests/wr1":
+			_, _ = w.Write([]byte(`{"id":"wr1","status":"COMPLETED","percentComplete":100}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	dstHandler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", "5")
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}
+	srcFs, srcServer := newTestFs(t, "bucket", srcHandler, nil)
+	dstFs, dstServer := newTestFs(t, "dstbucket", dstHandler, configmap.Simple{
+		"region":          "eu-frankfurt-1",
+		"namespace":       "otherns",
+		"no_check_bucket": "true",
+	})
+	srcObj := &Object{fs: srcFs, remote: "file.txt", meta: map[string]string{"mtime": "1"}}
+
+	// the metadata is copied by the service by default
+	want := objectstorage.CopyObjectDetails{
+		SourceObjectName:      common.String("file.txt"),
+		DestinationRegion:     common.String("eu-frankfurt-1"),
+		DestinationNamespace:  common.String("otherns"),
+		DestinationBucket:     common.String("dstbucket"),
+		DestinationObjectName: common.String("copy.txt"),
+	}
+	assert.Equal(t, want, copyObjectDetails(&Object{fs: dstFs, remote: "copy.txt"}, srcObj, nil, false))
+
+	dstObj, err := dstFs.Copy(ctx, srcObj, "copy.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), dstObj.Size())
+	assert.Equal(t, want, details)
+	assert.Equal(t, 1, srcServer.count(http.MethodPost))
+	assert.Equal(t, 0, dstServer.count(http.MethodPost))
+}
+
+func TestCopyAcrossAuthProviders(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	var copyIDs []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/actions/copyObject") {
+			copyIDs = append(copyIDs, r.Header.Get("opc-client-request-id"))
+		}
+		store.handler(w, r)
+	}
+	srcFs, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+		"no_check_bucket":          "true",
+		"client_request_id_prefix": "src",
+	})
+	srcFs.name = "src"
+	dstFs, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+		"no_check_bucket":          "true",
+		"endpoint":                 srcFs.srv.Host,
+		"client_request_id_prefix": "dst",
+	})
+	dstFs.name = "dst"
+	assert.True(t, dstFs.Features().ServerSideAcrossConfigs)
+	srcObj := putTestObject(t, srcFs, "file.txt", []byte("hello"))
+
+	// remotes in the same namespace and region copy server-side with
+	// the credentials of the destination
+	_, err := dstFs.Copy(ctx, srcObj, "copy.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(store.objects["copy.txt"]))
+	require.Len(t, copyIDs, 1)
+	assert.True(t, strings.HasPrefix(copyIDs[0], "dst-"), copyIDs[0])
+
+	// other namespaces need server_side_across_configs
+	otherFs, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+		"no_check_bucket": "true",
+		"namespace":       "otherns",
+	})
+	otherFs.name = "other"
+	_, err = otherFs.Copy(ctx, srcObj, "copy.txt")
+	assert.ErrorIs(t, err, fs.ErrorCantCopy)
+	assert.Len(t, copyIDs, 1)
+}
+
+func TestCopyAnySize(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name     string
+		size     int64
+		reject   int
+		wantCopy bool
+	}{
+		{name: "Small", size: 9, wantCopy: true},
+		{name: "AboveCutoff", size: 11, wantCopy: true},
+		{name: "Huge", size: 50 * 1024 * 1024 * 1024, wantCopy: true},
+		{name: "Rejected", size: 9, reject: http.StatusBadRequest},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodPost && r.URL.Path == "/n/testns/b/bucket/actions/copyObject":
+					if test.reject != 0 {
+						w.WriteHeader(test.reject)
+						return
+					}
+					w.Header().Set("opc-work-request-id", "wr1")
+				case r.URL.Path == "/workRequests/wr1":
+					_, _ = w.Write([]byte(`{"id":"wr1","status":"COMPLETED","percentComplete":100}`))
+				case r.Method == http.MethodHead:
+					w.Header().Set("Content-Length", "1")
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}
+			f, ts := newTestFs(t, "bucket", handler, configmap.Simple{
+				"copy_cutoff":     "10B",
+				"no_check_bucket": "true",
+			})
+			srcObj := &Object{fs: f, remote: "file.txt", bytes: test.size, meta: map[string]string{}}
+			_, err := f.Copy(ctx, srcObj, "copy.txt")
+			err2 := srcObj.SetModTime(ctx, time.Now())
+			// objects of any size are copied server-side
+			assert.Equal(t, 2, ts.count(http.MethodPost))
+			if test.wantCopy {
+				assert.NoError(t, err)
+				assert.NoError(t, err2)
+			} else {
+				// but fall back to download and upload if the service refuses
+				assert.ErrorIs(t, err, fs.ErrorCantCopy)
+				assert.ErrorIs(t, err2, fs.ErrorCantSetModTime)
+			}
+		})
+	}
+}
+
+func TestDisableServerSideCopy(t *testing.T) {
+	ctx := context.Background()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+	f, ts := newTestFs(t, "bucket", handler, configmap.Simple{
+		"disable_server_side_copy": "true",
+		"no_check_bucket":          "true",
+	})
+	srcObj := &Object{fs: f, remote: "file.txt", bytes: 1, meta: map[string]string{}}
+	_, err := f.Copy(ctx, srcObj, "copy.txt")
+	assert.ErrorIs(t, err, fs.ErrorCantCopy)
+	assert.ErrorIs(t, srcObj.SetModTime(ctx, time.Now()), fs.ErrorCantSetModTime)
+	assert.Empty(t, ts.requests)
+}
+
+func TestCopyRetriesNotFound(t *testing.T) {
+	ctx := context.Background()
+	var heads int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/n/testns/b/bucket/actions/copyObject":
+			w.Header().Set("opc-work-request-id", "wr1")
+		case r.URL.Path == "/workRequests/wr1":
+			_, _ = w.Write([]byte(`{"id":"wr1","status":"COMPLETED","percentComplete":100}`))
+		case r.Method == http.MethodHead && r.URL.Path == "/n/testns/b/bucket/o/copy.txt":
+			// the copy isn't visible on the first HEAD
+			if atomic.AddInt32(&heads, 1) == 1 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", "5")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	f, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+		"no_check_bucket": "true",
+	})
+	f.pacer.SetRetries(10)
+	srcObj := &Object{fs: f, remote: "file.txt", bytes: 5, meta: map[string]string{}}
+	dstObj, err := f.Copy(ctx, srcObj, "copy.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), dstObj.Size())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&heads))
+
+	// objects which are never found give up after a few retries
+	o := &Object{fs: f, remote: "missing.txt"}
+	_, err = o.headObjectRetryNotFound(ctx, 2)
+	assert.ErrorIs(t, err, fs.ErrorObjectNotFound)
+}
+
+func TestRequestTimeout(t *testing.T) {
+	ctx := context.Background()
+	var heads int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		// the first HEAD hangs until the client gives up
+		if atomic.AddInt32(&heads, 1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Length", "5")
+	}
+	f, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+		"request_timeout": "100ms",
+	})
+	f.pacer.SetRetries(2)
+	start := time.Now()
+	o, err := f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), o.Size())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&heads))
+	assert.Less(t, time.Since(start), 10*time.Second)
+
+	// without retries the hang is cut off at the timeout
+	atomic.StoreInt32(&heads, 0)
+	f.pacer.SetRetries(1)
+	start = time.Now()
+	_, err = f.NewObject(ctx, "file.txt")
+	require.Error(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestMaxRetries(t *testing.T) {
+	var heads int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&heads, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	regInfo, err := fs.Find("oracleobjectstorage")
+	require.NoError(t, err)
+	newFs := func(ctx context.Context, maxRetries string) (fs.Fs, error) {
+		return NewFs(ctx, "TestOOS", "bucket", fs.ConfigMap(regInfo, "TestOOS", configmap.Simple{
+			"provider":        noAuth,
+			"namespace":       "testns",
+			"endpoint":        server.URL,
+			"no_check_bucket": "true",
+			"max_retries":     maxRetries,
+		}))
+	}
+	ctx := context.Background()
+	for _, test := range []struct {
+		maxRetries string
+		wantHeads  int32
+	}{
+		{maxRetries: "3", wantHeads: 3},
+		{maxRetries: "1", wantHeads: 1},
+	} {
+		t.Run(test.maxRetries, func(t *testing.T) {
+			f, err := newFs(ctx, test.maxRetries)
+			require.NoError(t, err)
+			atomic.StoreInt32(&heads, 0)
+			_, err = f.NewObject(ctx, "file.txt")
+			require.Error(t, err)
+			assert.Equal(t, test.wantHeads, atomic.LoadInt32(&heads))
+		})
+	}
+	// the SDK keeps its retries by default
+	f, err := newFs(ctx, "0")
+	require.NoError(t, err)
+	assert.Nil(t, f.(*Fs).srv.RetryPolicy())
+	_, err = newFs(ctx, "-1")
+	assert.ErrorContains(t, err, "max_retries -1 must not be negative")
+}
+
+func TestCopyPreserveTier(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name      string
+		preserve  bool
+		srcTier   string // tier read from the listing if set
+		wantTier  objectstorage.StorageTierEnum
+		wantHeads int
+	}{
+		{name: "Default", srcTier: "InfrequentAccess", wantHeads: 1},
+		{name: "FromListing", preserve: true, srcTier: "InfrequentAccess", wantTier: objectstorage.StorageTierInfrequentAccess, wantHeads: 1},
+		{name: "FromHead", preserve: true, wantTier: objectstorage.StorageTierArchive, wantHeads: 2},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var details objectstorage.CopyObjectDetails
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodPost && r.URL.Path == "/n/testns/b/bucket/actions/copyObject":
+					assert.NoError(t, json.NewDecoder(r.Body).Decode(&details))
+					w.Header().Set("opc-work-request-id", "wr1")
+				case r.URL.Path == "/workRequests/wr1":
+					_, _ = w.Write([]byte(`{"id":"wr1","status":"COMPLETED","percentComplete":100}`))
+				case r.Method == http.MethodHead:
+					w.Header().Set("Content-Length", "5")
+					w.Header().Set("storage-tier", "Archive")
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}
+			f, ts := newTestFs(t, "bucket", handler, configmap.Simple{
+				"no_check_bucket": "true",
+				"preserve_tier":   fmt.Sprint(test.preserve),
+			})
+			srcObj := &Object{fs: f, remote: "file.txt", bytes: 5}
+			if test.srcTier != "" {
+				srcObj.storageTier = storageTierMap[strings.ToLower(test.srcTier)]
+			}
+			_, err := f.Copy(ctx, srcObj, "copy.txt")
+			require.NoError(t, err)
+			assert.Equal(t, test.wantTier, details.DestinationObjectStorageTier)
+			assert.Equal(t, test.wantHeads, ts.count(http.MethodHead))
+		})
+	}
+}
+
+func TestCopyEncoding(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	// the source stores names raw but the destination can't store
+	// some of the characters in them
+	srcFs, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{
+		"no_check_bucket": "true",
+		"encoding":        "Slash,Dot",
+	})
+	dstFs, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{
+		"no_check_bucket": "true",
+		"encoding":        "Slash,Dot,Asterisk,Colon,Question,BackSlash",
+		"endpoint":        srcFs.srv.Host,
+	})
+	const name = `a*b:c?d\e.txt`
+	srcObj := putTestObject(t, srcFs, "src/"+name, []byte("hello"))
+	require.Contains(t, store.objects, "src/"+name)
+
+	dstObj, err := dstFs.Copy(ctx, srcObj, "copy/"+name)
+	require.NoError(t, err)
+	assert.Equal(t, "copy/"+name, dstObj.Remote())
+	assert.Equal(t, "hello", string(store.objects["copy/a＊b：c？d＼e.txt"]))
+
+	err = dstFs.DirMove(ctx, srcFs, "src", "moved")
+	require.NoError(t, err)
+	assert.NotContains(t, store.objects, "src/"+name)
+	assert.Equal(t, "hello", string(store.objects["moved/a＊b：c？d＼e.txt"]))
+
+	// the names are decoded again when listed
+	for _, dir := range []string{"copy", "moved"} {
+		entries, err := dstFs.List(ctx, dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, dir+"/"+name, entries[0].Remote())
+		o, err := dstFs.NewObject(ctx, dir+"/"+name)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), o.Size())
+	}
+}
+
+func TestCopyAcrossBuckets(t *testing.T) {
+	ctx := context.Background()
+	var details objectstorage.CopyObjectDetails
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/n/testns/b/bucketA/actions/copyObject":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&details))
+			w.Header().Set("opc-work-request-id", "wr1")
+		case r.Method == http.MethodPost && r.URL.Path == "/n/otherns/b/bucketA/actions/copyObject":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"NotAuthorizedOrNotFound","message":"Authorization failed or requested resource not found"}`))
+		case r.URL.Path == "/workRequests/wr1":
+			_, _ = w.Write([]byte(`{"id":"wr1","status":"COMPLETED","percentComplete":100}`))
+		case r.Method == http.MethodHead && strings.HasPrefix(r.URL.Path, "/n/testns/b/bucketB/o/"):
+			w.Header().Set("Content-Length", "5")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	f, ts := newTestFs(t, "", handler, configmap.Simple{
+		"no_check_bucket": "true",
+	})
+	srcObj := &Object{fs: f, remote: "bucketA/file.txt", bytes: 5}
+	dstObj, err := f.Copy(ctx, srcObj, "bucketB/file.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "bucketB/file.txt", dstObj.Remote())
+	assert.Equal(t, "bucketB", *details.DestinationBucket)
+	assert.Equal(t, "testns", *details.DestinationNamespace)
+	assert.Equal(t, 1, ts.count(http.MethodPost))
+	assert.Equal(t, 0, ts.count(http.MethodPut))
+	for _, request := range ts.requests {
+		assert.NotContains(t, request, "GET /n/testns/b/bucketA/o/", "no download of the source")
+	}
+
+	// copies from another namespace which aren't authorized fall back
+	// to download and upload
+	otherFs, _ := newTestFs(t, "", handler, configmap.Simple{
+		"namespace":       "otherns",
+		"no_check_bucket": "true",
+	})
+	srcObj = &Object{fs: otherFs, remote: "bucketA/file.txt", bytes: 5}
+	_, err = f.Copy(ctx, srcObj, "bucketB/file.txt")
+	assert.ErrorIs(t, err, fs.ErrorCantCopy)
+
+	assert.True(t, isNotAuthorized(testServiceError{status: http.StatusNotFound, code: "NotAuthorizedOrNotFound"}))
+	assert.False(t, isNotAuthorized(testServiceError{status: http.StatusNotFound, code: "ObjectNotFound"}))
+}
+
+func TestMove(t *testing.T) {
+	ctx := context.Background()
+	src, dst := newMemoryStore(), newMemoryStore()
+	truncate := false
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if other := strings.TrimPrefix(r.URL.Path, "/n/testns/b/other"); other != r.URL.Path {
+			// the other bucket is kept in dst
+			r.URL.Path = "/n/testns/b/bucket" + other
+			dst.handler(w, r)
+			return
+		}
+		if r.Method == http.MethodPost && r.URL.Path == "/n/testns/b/bucket/actions/copyObject" {
+			var details objectstorage.CopyObjectDetails
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&details))
+			if *details.DestinationBucket == "other" {
+				src.mu.Lock()
+				dst.mu.Lock()
+				name := *details.DestinationObjectName
+				dst.objects[name] = src.objects[*details.SourceObjectName]
+				if truncate {
+					dst.objects[name] = dst.objects[name][:1]
+				}
+				dst.meta[name] = src.meta[*details.SourceObjectName].Clone()
+				// copies get the default tier unless it is given
+				dst.meta[name].Del("storage-tier")
+				if details.DestinationObjectStorageTier != "" {
+					dst.meta[name].Set("storage-tier", string(details.DestinationObjectStorageTier))
+				}
+				dst.mu.Unlock()
+				src.mu.Unlock()
+				w.Header().Set("opc-work-request-id", "wr-copy")
+				return
+			}
+		}
+		src.handler(w, r)
+	}
+	f, ts := newTestFs(t, "", handler, configmap.Simple{"no_check_bucket": "true"})
+	modTime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	put := func(remote string) fs.Object {
+		o := putTestObject(t, f, "bucket/"+remote, []byte("hello"))
+		src.mu.Lock()
+		src.meta[remote].Set("opc-meta-colour", "blue")
+		src.meta[remote].Set("Content-Type", "text/html")
+		src.meta[remote].Set("storage-tier", "InfrequentAccess")
+		src.mu.Unlock()
+		o, err := f.NewObject(ctx, "bucket/"+remote)
+		require.NoError(t, err)
+		return o
+	}
+	checkMoved := func(o fs.Object, remote string) {
+		assert.Equal(t, remote, o.Remote())
+		assert.Equal(t, int64(5), o.Size())
+		assert.Equal(t, "text/html", o.(fs.MimeTyper).MimeType(ctx))
+		assert.Equal(t, infrequentAccess, o.(fs.GetTierer).GetTier())
+		assert.True(t, modTime.Equal(o.ModTime(ctx)), "mtime %v", o.ModTime(ctx))
+		meta, err := o.(fs.Metadataer).Metadata(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "blue", meta["colour"])
+	}
+
+	// across buckets the object is copied then deleted
+	o := put("file.txt")
+	moved, err := f.Move(ctx, o, "other/moved.txt")
+	require.NoError(t, err)
+	checkMoved(moved, "other/moved.txt")
+	assert.Contains(t, dst.objects, "moved.txt")
+	assert.NotContains(t, src.objects, "file.txt")
+	_, err = f.NewObject(ctx, "bucket/file.txt")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+
+	// the source is kept if the copy doesn't match it
+	truncate = true
+	o = put("bad.txt")
+	_, err = f.Move(ctx, o, "other/bad.txt")
+	assert.ErrorContains(t, err, "not deleting the source")
+	assert.Contains(t, src.objects, "bad.txt")
+	truncate = false
+
+	// within a bucket the object is renamed
+	copies := ts.count(http.MethodPost)
+	o = put("rename.txt")
+	moved, err = f.Move(ctx, o, "bucket/renamed.txt")
+	require.NoError(t, err)
+	checkMoved(moved, "bucket/renamed.txt")
+	assert.NotContains(t, src.objects, "rename.txt")
+	assert.Contains(t, ts.requests, "POST /n/testns/b/bucket/actions/renameObject")
+	assert.Equal(t, copies+1, ts.count(http.MethodPost))
+}
+
+func TestCopyMetadataDirective(t *testing.T) {
+	var details objectstorage.CopyObjectDetails
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/n/testns/b/bucket/actions/copyObject":
+			details = objectstorage.CopyObjectDetails{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&details))
+			w.Header().Set("opc-work-request-id", "wr1")
+		case r.URL.Path == "/workRequests/wr1":
+			_, _ = w.Write([]byte(`{"id":"wr1","status":"COMPLETED","percentComplete":100}`))
+		case r.Method == http.MethodHead && r.URL.Path == "/n/testns/b/bucket/o/file.txt":
+			w.Header().Set("Content-Length", "5")
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("opc-meta-mtime", "1")
+		case r.Method == http.MethodHead && r.URL.Path == "/n/testns/b/bucket/o/copy.txt":
+			w.Header().Set("Content-Length", "5")
+			contentType := "text/plain"
+			if details.DestinationObjectMetadata != nil {
+				contentType = details.DestinationObjectMetadata["content-type"]
+			}
+			w.Header().Set("Content-Type", contentType)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	f, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+		"no_check_bucket": "true",
+	})
+	srcObj := &Object{fs: f, remote: "file.txt", bytes: 5}
+
+	// COPY: without metadata to set it is left to the service to copy
+	ctx := context.Background()
+	dstObj, err := f.Copy(ctx, srcObj, "copy.txt")
+	require.NoError(t, err)
+	assert.Nil(t, details.DestinationObjectMetadata)
+	assert.Equal(t, "text/plain", dstObj.(*Object).MimeType(ctx))
+
+	// REPLACE: metadata set with --metadata --metadata-set is merged
+	// with the source's and replaces it
+	ctx, ci := fs.AddConfig(ctx)
+	ci.Metadata = true
+	ci.MetadataSet = fs.Metadata{"content-type": "text/html", "owner": "rclone"}
+	dstObj, err = f.Copy(ctx, srcObj, "copy.txt")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"content-type":   "text/html",
+		"opc-meta-mtime": "1",
+		"opc-meta-owner": "rclone",
+	}, details.DestinationObjectMetadata)
+	assert.Equal(t, "text/html", dstObj.(*Object).MimeType(ctx))
+}
+
+// memoryStore is a minimal in memory object storage service for
+// testing uploads
+type memoryStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	meta    map[string]http.Header
+	parts   map[string]map[int][]byte    // parts by upload ID and part number
+	pending map[string]map[string]string // metadata of pending uploads
+	partMD5 map[string]string            // listed MD5s of multipart objects
+	etags   map[string]string            // ETags of the objects
+	removed bool                         // set once the bucket has been deleted
+	aborted int
+	corrupt int // if set, corrupt this part number when committing
+	commits int // number of multipart uploads committed
+	healed  int // if set, stop corrupting parts after this many commits
+	crc32cs int // number of uploads with a valid CRC32C header
+	md5s    int // number of single part uploads with a valid Content-MD5 header
+	garbles int // number of single part uploads to corrupt on the way
+	writes  int // number of objects written, which numbers their ETags
+}
+
+// checkConditions checks the If-Match and If-None-Match headers of r
+// against the object name, failing r with 412 Precondition Failed if
+// they don't match
+func (m *memoryStore) checkConditions(w http.ResponseWriter, r *http.Request, name string) bool {
+	_, exists := m.objects[name]
+	ifMatch, ifNoneMatch := r.Header.Get("If-Match"), r.Header.Get("If-None-Match")
+	if (ifNoneMatch == "*" && exists) || (ifMatch != "" && (!exists || m.etags[name] != ifMatch)) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+// setETag gives the object name which has just been written a new ETag
+func (m *memoryStore) setETag(w http.ResponseWriter, name string) {
+	m.writes++
+	etag := fmt.Sprintf("etag-%d", m.writes)
+	m.etags[name] = etag
+	w.Header().Set("ETag", etag)
+}
+
+// checkContentMD5 checks the Content-MD5 header of r against body if
+// present, corrupting body first if garbles is set
+func (m *memoryStore) checkContentMD5(w http.ResponseWriter, r *http.Request, body []byte) bool {
+	contentMD5 := r.Header.Get("Content-MD5")
+	if contentMD5 == "" {
+		return true
+	}
+	if m.garbles > 0 && len(body) > 0 {
+		m.garbles--
+		body[0] ^= 0xFF
+	}
+	sum := md5.Sum(body)
+	if got := base64.StdEncoding.EncodeToString(sum[:]); got != contentMD5 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, `{"code":"UnmatchedContentMD5","message":"The computed MD5 of the request body (%s) does not match the Content-MD5 header (%s)"}`, got, contentMD5)
+		return false
+	}
+	m.md5s++
+	return true
+}
+
+// checkCRC32C checks the CRC32C header of r against body if present
+func (m *memoryStore) checkCRC32C(r *http.Request, body []byte) bool {
+	crc := r.Header.Get(crc32cHeader)
+	if crc == "" {
+		return true
+	}
+	if crc != crc32cBase64(body) {
+		return false
+	}
+	m.crc32cs++
+	return true
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		objects: map[string][]byte{},
+		meta:    map[string]http.Header{},
+		parts:   map[string]map[int][]byte{},
+		pending: map[string]map[string]string{},
+		partMD5: map[string]string{},
+		etags:   map[string]string{},
+	}
+}
+
+func (m *memoryStore) handler(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	const prefix = "/n/testns/b/bucket"
+	path := strings.TrimPrefix(r.URL.Path, prefix)
+	uploadID := r.URL.Query().Get("uploadId")
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "/o/"):
+		name := strings.TrimPrefix(path, "/o/")
+		if !m.checkCRC32C(r, body) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !m.checkContentMD5(w, r, body) || !m.checkConditions(w, r, name) {
+			return
+		}
+		m.objects[name] = body
+		m.meta[name] = http.Header{}
+		for k, v := range r.Header {
+			if strings.HasPrefix(strings.ToLower(k), ociMetaPrefix) {
+				m.meta[name][k] = v
+			}
+		}
+		sum := md5.Sum(body)
+		m.meta[name].Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+		m.setETag(w, name)
+	case r.Method == http.MethodHead && strings.HasPrefix(path, "/o/"):
+		name := strings.TrimPrefix(path, "/o/")
+		data, ok := m.objects[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for k, v := range m.meta[name] {
+			w.Header()[k] = v
+		}
+		w.Header().Set("ETag", m.etags[name])
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		w.Header().Set(crc32cHeader, crc32cBase64(data))
+	case r.Method == http.MethodPost && path == "/u":
+		var details objectstorage.CreateMultipartUploadDetails
+		_ = json.Unmarshal(body, &details)
+		if !m.checkConditions(w, r, *details.Object) {
+			return
+		}
+		uploadID = fmt.Sprintf("upload-%d", len(m.pending)+1)
+		m.parts[uploadID] = map[int][]byte{}
+		m.pending[uploadID] = details.Metadata
+		_, _ = fmt.Fprintf(w, `{"namespace":"testns","bucket":"bucket","object":%q,"uploadId":%q,"timeCreated":"2020-01-01T00:00:00Z"}`,
+			*details.Object, uploadID)
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "/u/"):
+		partNum, _ := strconv.Atoi(r.URL.Query().Get("uploadPartNum"))
+		if md5sum := r.Header.Get("Content-MD5"); md5sum != "" {
+			sum := md5.Sum(body)
+			if md5sum != base64.StdEncoding.EncodeToString(sum[:]) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		if !m.checkCRC32C(r, body) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		m.parts[uploadID][partNum] = body
+		w.Header().Set("ETag", fmt.Sprintf("etag-%d", partNum))
+	case r.Method == http.MethodPost && strings.HasPrefix(path, "/u/"):
+		name := strings.TrimPrefix(path, "/u/")
+		var details objectstorage.CommitMultipartUploadDetails
+		_ = json.Unmarshal(body, &details)
+		if !m.checkConditions(w, r, name) {
+			return
+		}
+		var data, md5s []byte
+		for _, part := range details.PartsToCommit {
+			partData := m.parts[uploadID][*part.PartNum]
+			if *part.PartNum == m.corrupt {
+				partData = append([]byte{partData[0] ^ 0xFF}, partData[1:]...)
+			}
+			sum := md5.Sum(partData)
+			md5s = append(md5s, sum[:]...)
+			data = append(data, partData...)
+		}
+		m.commits++
+		if m.healed > 0 && m.commits >= m.healed {
+			m.corrupt = 0
+		}
+		sum := md5.Sum(md5s)
+		multipartMD5 := fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(sum[:]), len(details.PartsToCommit))
+		w.Header().Set("opc-multipart-md5", multipartMD5)
+		m.objects[name] = data
+		m.meta[name] = http.Header{}
+		for k, v := range m.pending[uploadID] {
+			m.meta[name].Set(k, v)
+		}
+		m.setETag(w, name)
+		m.partMD5[name] = multipartMD5
+		delete(m.parts, uploadID)
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "/o/"):
+		delete(m.objects, strings.TrimPrefix(path, "/o/"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "/u/"):
+		delete(m.parts, uploadID)
+		m.aborted++
+	case r.Method == http.MethodPost && path == "/actions/copyObject":
+		var details objectstorage.CopyObjectDetails
+		_ = json.Unmarshal(body, &details)
+		dst := *details.DestinationObjectName
+		m.copyObject(*details.SourceObjectName, dst)
+		if details.DestinationObjectMetadata != nil {
+			// replace the user metadata and content headers
+			meta := http.Header{"Content-Md5": m.meta[dst]["Content-Md5"]}
+			for k, v := range details.DestinationObjectMetadata {
+				meta.Set(k, v)
+			}
+			m.meta[dst] = meta
+		}
+		if details.DestinationObjectStorageTier != "" {
+			m.meta[dst].Set("storage-tier", string(details.DestinationObjectStorageTier))
+		}
+		w.Header().Set("opc-work-request-id", "wr-copy")
+	case r.Method == http.MethodGet && path == "/workRequests/wr-copy":
+		_, _ = w.Write([]byte(`{"id":"wr-copy","status":"COMPLETED","percentComplete":100}`))
+	case r.Method == http.MethodPost && path == "/actions/renameObject":
+		var details objectstorage.RenameObjectDetails
+		_ = json.Unmarshal(body, &details)
+		if _, ok := m.objects[*details.NewName]; ok && details.NewObjIfNoneMatchETag != nil && *details.NewObjIfNoneMatchETag == "*" {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		m.copyObject(*details.SourceName, *details.NewName)
+		delete(m.objects, *details.SourceName)
+		delete(m.meta, *details.SourceName)
+		delete(m.partMD5, *details.SourceName)
+	case r.Method == http.MethodGet && path == "/o":
+		m.list(w, r)
+	case r.Method == http.MethodDelete && path == "":
+		if len(m.objects) > 0 {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		m.removed = true
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/o/"):
+		data, ok := m.objects[strings.TrimPrefix(path, "/o/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		_, _ = w.Write(data)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// copyObject copies the object src to dst in the store
+func (m *memoryStore) copyObject(src, dst string) {
+	m.objects[dst] = m.objects[src]
+	m.meta[dst] = m.meta[src].Clone()
+	if md5sum, ok := m.partMD5[src]; ok {
+		m.partMD5[dst] = md5sum
+	}
+}
+
+// list lists the objects in the store with the prefix and delimiter
+// of the request in one page
+func (m *memoryStore) list(w http.ResponseWriter, r *http.Request) {
+	prefix, delimiter := r.URL.Query().Get("prefix"), r.URL.Query().Get("delimiter")
+	start := r.URL.Query().Get("start")
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	var names []string
+	for name := range m.objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result objectstorage.ListObjects
+	prefixes := map[string]bool{}
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) || name < start {
+			continue
+		}
+		if limit > 0 && len(result.Objects)+len(result.Prefixes) >= limit {
+			result.NextStartWith = common.String(name)
+			break
+		}
+		if delimiter != "" {
+			if i := strings.Index(name[len(prefix):], delimiter); i >= 0 {
+				dir := name[:len(prefix)+i+1]
+				if !prefixes[dir] {
+					prefixes[dir] = true
+					result.Prefixes = append(result.Prefixes, dir)
+				}
+				continue
+			}
+		}
+		summary := objectstorage.ObjectSummary{
+			Name: common.String(name),
+			Size: common.Int64(int64(len(m.objects[name]))),
+		}
+		if md5sum := m.meta[name].Get("Content-MD5"); md5sum != "" {
+			summary.Md5 = common.String(md5sum)
+		} else if md5sum, ok := m.partMD5[name]; ok {
+			summary.Md5 = common.String(md5sum)
+		}
+		result.Objects = append(result.Objects, summary)
+	}
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{"no_check_bucket": "true"})
+	remotes := []string{
+		"a..",
+		"dir/a..",
+		"dir/file.",
+		"dir//file",
+		"dir/．/file",
+		"it's",
+	}
+	for _, remote := range remotes {
+		putTestObject(t, f, remote, []byte(remote))
+		o, err := f.NewObject(ctx, remote)
+		require.NoError(t, err, remote)
+		assert.Equal(t, remote, o.Remote())
+	}
+	// the names aren't cleaned on the way to object storage
+	var keys []string
+	for key := range store.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	assert.Equal(t, []string{"a..", "dir//file", "dir/a..", "dir/file.", "dir/．/file", "it's"}, keys)
+
+	// and list back the same
+	var listed []string
+	err := walk.ListR(ctx, f, "", true, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			listed = append(listed, entry.Remote())
+			in, err := entry.(fs.Object).Open(ctx)
+			require.NoError(t, err)
+			data, err := io.ReadAll(in)
+			require.NoError(t, err)
+			require.NoError(t, in.Close())
+			assert.Equal(t, entry.Remote(), string(data))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	sort.Strings(remotes)
+	sort.Strings(listed)
+	assert.Equal(t, remotes, listed)
+}
+
+func TestRootPrefix(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	config := configmap.Simple{"no_check_bucket": "true", "root_prefix": "/job1/"}
+	f, _ := newTestFs(t, "bucket/deep", store.handler, config)
+	o := putTestObject(t, f, "dir/file.txt", []byte("hello"))
+	assert.Equal(t, "dir/file.txt", o.Remote())
+	_, ok := store.objects["job1/deep/dir/file.txt"]
+	assert.True(t, ok, "object stored under the prefix")
+
+	// a trailing slash on the root makes no difference
+	for _, root := range []string{"bucket/deep", "bucket/deep/"} {
+		t.Run(root, func(t *testing.T) {
+			f, _ := newTestFs(t, root, store.handler, config)
+			o, err := f.NewObject(ctx, "dir/file.txt")
+			require.NoError(t, err)
+			assert.Equal(t, "dir/file.txt", o.Remote())
+			assert.Equal(t, int64(5), o.Size())
+
+			entries, err := f.List(ctx, "")
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.Equal(t, "dir", entries[0].Remote())
+			entries, err = f.List(ctx, "dir")
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.Equal(t, "dir/file.txt", entries[0].Remote())
+
+			var listed []string
+			err = f.ListR(ctx, "", func(entries fs.DirEntries) error {
+				for _, entry := range entries {
+					listed = append(listed, entry.Remote())
+				}
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"dir/file.txt"}, listed)
+		})
+	}
+
+	// the root of the remote can point at the object
+	server := httptest.NewServer(http.HandlerFunc(store.handler))
+	defer server.Close()
+	regInfo, err := fs.Find("oracleobjectstorage")
+	require.NoError(t, err)
+	_, err = NewFs(ctx, "TestOOS", "bucket/deep/dir/file.txt", fs.ConfigMap(regInfo, "TestOOS", configmap.Simple{
+		"provider":    noAuth,
+		"namespace":   "testns",
+		"region":      "us-ashburn-1",
+		"endpoint":    server.URL,
+		"root_prefix": "job1",
+	}))
+	assert.Equal(t, fs.ErrorIsFile, err)
+
+	// server-side copies stay under the prefix
+	dst, err := f.Copy(ctx, o, "copy.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "copy.txt", dst.Remote())
+	_, ok = store.objects["job1/deep/copy.txt"]
+	assert.True(t, ok, "copy stored under the prefix")
+
+	// without the prefix the objects are seen where they are
+	plain, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{"no_check_bucket": "true"})
+	_, err = plain.NewObject(ctx, "job1/deep/dir/file.txt")
+	require.NoError(t, err)
+}
+
+func TestJoinPath(t *testing.T) {
+	for _, test := range []struct {
+		dir, name, want string
+	}{
+		{"", "", ""},
+		{"bucket", "", "bucket"},
+		{"", "file", "file"},
+		{"bucket", "dir/file", "bucket/dir/file"},
+		{"bucket", "dir//file", "bucket/dir//file"},
+		{"bucket/dir", "a..", "bucket/dir/a.."},
+	} {
+		assert.Equal(t, test.want, joinPath(test.dir, test.name))
+	}
+}
+
+func TestStreamPartSize(t *testing.T) {
+	chunkSize := fs.SizeSuffix(5 * 1024 * 1024)
+	assert.Equal(t, chunkSize, streamPartSize(chunkSize, 1))
+	assert.Equal(t, chunkSize, streamPartSize(chunkSize, streamPartsPerDoubling))
+	assert.Equal(t, 2*chunkSize, streamPartSize(chunkSize, streamPartsPerDoubling+1))
+	assert.Equal(t, maxChunkSize, streamPartSize(maxChunkSize/2, 2*streamPartsPerDoubling+1))
+	// streams well beyond the 48 GiB of fixed size parts fit in the parts limit
+	var total fs.SizeSuffix
+	for partNum := 1; partNum <= maxUploadParts; partNum++ {
+		total += streamPartSize(chunkSize, partNum)
+	}
+	assert.Greater(t, total, fs.SizeSuffix(1024*1024*1024*1024))
+}
+
+func TestUploadUnknownSize(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name      string
+		size      int
+		wantPuts  int
+		wantPosts int
+	}{
+		{name: "Empty", size: 0, wantPuts: 1, wantPosts: 0},
+		{name: "OneChunk", size: 1000, wantPuts: 1, wantPosts: 0},
+		{name: "Multipart", size: 2500, wantPuts: 3, wantPosts: 2},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			store := newMemoryStore()
+			f, ts := newTestFs(t, "bucket", store.handler, configmap.Simple{
+				"no_check_bucket": "true",
+				"chunk_size":      "1k",
+			})
+			contents := random.String(test.size)
+			src := object.NewStaticObjectInfo("file.txt", time.Now(), -1, true, nil, f)
+			o, err := f.Put(ctx, strings.NewReader(contents), src)
+			require.NoError(t, err)
+			assert.Equal(t, int64(test.size), o.Size())
+			assert.Equal(t, contents, string(store.objects["file.txt"]))
+			assert.Equal(t, test.wantPuts, ts.count(http.MethodPut))
+			assert.Equal(t, test.wantPosts, ts.count(http.MethodPost))
+		})
+	}
+}
+
+func TestUploadUnknownSizeWarnsNearPartLimit(t *testing.T) {
+	ctx := context.Background()
+	var logs bytes.Buffer
+	oldLogPrint := fs.LogPrint
+	fs.LogPrint = func(level fs.LogLevel, text string) {
+		logs.WriteString(text + "\n")
+	}
+	defer func() {
+		fs.LogPrint = oldLogPrint
+	}()
+	store := newMemoryStore()
+	f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{
+		"no_check_bucket":  "true",
+		"chunk_size":       "1k",
+		"max_upload_parts": "10",
+	})
+	assert.Equal(t, fs.SizeSuffix(10*1024), streamMaxSize(f.opt.ChunkSize, 10))
+
+	// a stream which fits before most of the parts are used
+	src := object.NewStaticObjectInfo("file.txt", time.Now(), -1, true, nil, f)
+	_, err := f.Put(ctx, strings.NewReader(random.String(7*1024)), src)
+	require.NoError(t, err)
+	assert.NotContains(t, logs.String(), "Streaming upload")
+
+	// and one which doesn't
+	contents := random.String(9 * 1024)
+	_, err = f.Put(ctx, strings.NewReader(contents), src)
+	require.NoError(t, err)
+	assert.Equal(t, contents, string(store.objects["file.txt"]))
+	assert.Contains(t, logs.String(), "Streaming upload has reached part 8 of the 10 parts allowed and 8Ki, it will fail if the stream is bigger than 10Ki: increase chunk_size for streams this big")
+}
+
+func TestUploadMultipartTooManyParts(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	var inFlight, inFlightAtAbort int32 = 0, -1
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/u/"):
+			atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			time.Sleep(50 * time.Millisecond)
+		case r.Method == http.MethodDelete && strings.Contains(r.URL.Path, "/u/"):
+			atomic.StoreInt32(&inFlightAtAbort, atomic.LoadInt32(&inFlight))
+		}
+		store.handler(w, r)
+	}
+	f, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+		"no_check_bucket":    "true",
+		"chunk_size":         "1k",
+		"max_upload_parts":   "10",
+		"upload_concurrency": "4",
+	})
+	src := object.NewStaticObjectInfo("file.txt", time.Now(), -1, true, nil, f)
+	_, err := f.Put(ctx, strings.NewReader(random.String(12*1024)), src)
+	assert.ErrorContains(t, err, "more than 10 parts needed")
+	// the upload is only aborted once the parts started have finished
+	assert.Equal(t, int32(0), atomic.LoadInt32(&inFlightAtAbort))
+	assert.Equal(t, 0, f.pool.InUse())
+	assert.Equal(t, 1, store.aborted)
+}
+
+func TestUploadMultipartMaxParts(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name    string
+		size    int
+		stream  bool
+		wantErr string
+	}{
+		{name: "Stream", size: 2 * 1024, stream: true},
+		{name: "Known", size: 2 * 1024},
+		{name: "StreamTooBig", size: 2*1024 + 1, stream: true, wantErr: "more than 2 parts needed"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			store := newMemoryStore()
+			f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{
+				"no_check_bucket":  "true",
+				"chunk_size":       "1k",
+				"max_upload_parts": "2",
+				"upload_cutoff":    "0",
+			})
+			contents := random.String(test.size)
+			size := int64(test.size)
+			if test.stream {
+				size = -1
+			}
+			src := object.NewStaticObjectInfo("file.txt", time.Now(), size, true, nil, f)
+			_, err := f.Put(ctx, io.MultiReader(strings.NewReader(contents)), src)
+			if test.wantErr != "" {
+				assert.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			// exactly max_upload_parts * chunk_size fits
+			require.NoError(t, err)
+			assert.Equal(t, contents, string(store.objects["file.txt"]))
+			assert.Equal(t, 1, store.commits)
+		})
+	}
+}
+
+func TestUploadSizeHint(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name          string
+		size          int64 // size given by src
+		uploadCutoff  string
+		wantPuts      int
+		wantPosts     int
+		wantPutLength int64 // largest Content-Length of the PUTs
+	}{
+		{name: "KnownSingle", size: 2500, uploadCutoff: "3k", wantPuts: 1, wantPosts: 0, wantPutLength: 2500},
+		{name: "KnownMultipart", size: 2500, uploadCutoff: "2k", wantPuts: 3, wantPosts: 2, wantPutLength: 1024},
+		{name: "Unknown", size: -1, uploadCutoff: "3k", wantPuts: 3, wantPosts: 2, wantPutLength: 1024},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			store := newMemoryStore()
+			var putLength int64
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				store.mu.Lock()
+				if r.Method == http.MethodPut && r.ContentLength > putLength {
+					putLength = r.ContentLength
+				}
+				store.mu.Unlock()
+				store.handler(w, r)
+			}
+			f, ts := newTestFs(t, "bucket", handler, configmap.Simple{
+				"no_check_bucket": "true",
+				"chunk_size":      "1k",
+				"upload_cutoff":   test.uploadCutoff,
+			})
+			contents := random.String(2500)
+			// hide the length of the reader from the upload
+			in := io.MultiReader(strings.NewReader(contents))
+			src := object.NewStaticObjectInfo("file.txt", time.Now(), test.size, true, nil, f)
+			o, err := f.Put(ctx, in, src)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(contents)), o.Size())
+			assert.Equal(t, contents, string(store.objects["file.txt"]))
+			assert.Equal(t, test.wantPuts, ts.count(http.MethodPut))
+			assert.Equal(t, test.wantPosts, ts.count(http.MethodPost))
+			assert.Equal(t, test.wantPutLength, putLength)
+		})
+	}
+}
+
+func TestUploadOverSinglePartLimit(t *testing.T) {
+	ctx := context.Background()
+	const size = 6 * 1024 * 1024 * 1024
+	var first string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if first == "" {
+			first = r.Method + " " + r.URL.Path
+		}
+		// stop the upload straight away
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	f, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+		"no_check_bucket": "true",
+		"upload_cutoff":   "100G",
+	})
+	assert.False(t, f.useMultipart(int64(maxUploadCutoff)))
+	assert.True(t, f.useMultipart(size))
+
+	src := object.NewStaticObjectInfo("file.bin", time.Now(), size, true, nil, f)
+	_, err := f.Put(ctx, io.MultiReader(readers.NewPatternReader(size)), src)
+	require.Error(t, err)
+	assert.Equal(t, "POST /n/testns/b/bucket/u", first)
+}
+
+func TestForceMultipart(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		config        configmap.Simple
+		size          int
+		wantMultipart bool
+	}{
+		{name: "Default", size: 1024 * 1024, wantMultipart: false},
+		{name: "Forced", config: configmap.Simple{"force_multipart": "true"}, size: 1024 * 1024, wantMultipart: true},
+		{name: "ZeroCutoff", config: configmap.Simple{"upload_cutoff": "0"}, size: 1024 * 1024, wantMultipart: true},
+		{name: "ForcedEmpty", config: configmap.Simple{"force_multipart": "true"}, size: 0, wantMultipart: false},
+		{name: "ZeroCutoffEmpty", config: configmap.Simple{"upload_cutoff": "0"}, size: 0, wantMultipart: false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			store := newMemoryStore()
+			config := configmap.Simple{"no_check_bucket": "true"}
+			for k, v := range test.config {
+				config[k] = v
+			}
+			f, ts := newTestFs(t, "bucket", store.handler, config)
+			contents := []byte(random.String(test.size))
+			o := putTestObject(t, f, "file.txt", contents)
+			assert.Equal(t, int64(test.size), o.Size())
+			assert.Equal(t, contents, store.objects["file.txt"])
+			if test.wantMultipart {
+				assert.Equal(t, 2, ts.count(http.MethodPost)) // create and commit
+			} else {
+				assert.Equal(t, 0, ts.count(http.MethodPost))
+				assert.Equal(t, 1, ts.count(http.MethodPut))
+			}
+		})
+	}
+}
+
+func TestNoHeadMultipart(t *testing.T) {
+	for _, noHead := range []bool{false, true} {
+		t.Run(fmt.Sprint(noHead), func(t *testing.T) {
+			store := newMemoryStore()
+			f, ts := newTestFs(t, "bucket", store.handler, configmap.Simple{
+				"no_check_bucket": "true",
+				"force_multipart": "true",
+				"no_head":         fmt.Sprint(noHead),
+			})
+			contents := []byte(random.String(1000))
+			o := putTestObject(t, f, "file.txt", contents)
+			assert.Equal(t, 2, ts.count(http.MethodPost)) // create and commit
+			if noHead {
+				assert.Equal(t, 0, ts.count(http.MethodHead))
+			} else {
+				assert.Equal(t, 1, ts.count(http.MethodHead))
+			}
+			assert.Equal(t, int64(len(contents)), o.Size())
+			assert.Equal(t, time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC), o.ModTime(context.Background()).UTC())
+			if noHead {
+				// the MD5 of the upload is known without a HEAD
+				sum, err := o.Hash(context.Background(), hash.MD5)
+				require.NoError(t, err)
+				assert.Equal(t, fmt.Sprintf("%x", md5.Sum(contents)), sum)
+				assert.Equal(t, 0, ts.count(http.MethodHead))
+			}
+		})
+	}
+}
+
+func TestUploadPartSize(t *testing.T) {
+	f, _ := newTestFs(t, "bucket", nil, nil)
+	o := &Object{fs: f, remote: "file.txt"}
+	const TiB = 1024 * 1024 * 1024 * 1024
+	for _, test := range []struct {
+		size            int64
+		uploadParts     int
+		wantConcurrency int
+	}{
+		{size: 100 * 1024 * 1024, uploadParts: maxUploadParts, wantConcurrency: defaultUploadConcurrency},
+		{size: 1 * TiB, uploadParts: maxUploadParts, wantConcurrency: 1},
+		{size: 1024 * 1024 * 1024, uploadParts: 100, wantConcurrency: 4},
+	} {
+		partSize, concurrency := f.uploadPartSize(o, test.size, test.uploadParts)
+		parts := (test.size + int64(partSize) - 1) / int64(partSize)
+		assert.LessOrEqual(t, parts, int64(test.uploadParts), "size %d", test.size)
+		assert.GreaterOrEqual(t, partSize, f.opt.ChunkSize)
+		assert.Equal(t, test.wantConcurrency, concurrency, "size %d", test.size)
+		assert.LessOrEqual(t, partSize*fs.SizeSuffix(concurrency), f.opt.ChunkSize*defaultUploadConcurrency+partSize)
+	}
+	partSize, concurrency := f.uploadPartSize(o, -1, maxUploadParts)
+	assert.Equal(t, f.opt.ChunkSize, partSize)
+	assert.Equal(t, defaultUploadConcurrency, concurrency)
+}
+
+func TestUploadConcurrencyAuto(t *testing.T) {
+	const MiB = 1024 * 1024
+	f, _ := newTestFs(t, "bucket", nil, configmap.Simple{"upload_concurrency": "auto"})
+	assert.Equal(t, autoUploadConcurrency, f.opt.UploadConcurrency)
+	assert.Equal(t, "auto", f.opt.UploadConcurrency.String())
+	o := &Object{fs: f, remote: "file.txt"}
+
+	_, small := f.uploadPartSize(o, 100*MiB, maxUploadParts)
+	_, large := f.uploadPartSize(o, 10*1024*MiB, maxUploadParts)
+	_, unknown := f.uploadPartSize(o, -1, maxUploadParts)
+	assert.Equal(t, 2, small)
+	assert.Equal(t, maxAutoUploadConcurrency, large)
+	assert.Equal(t, defaultUploadConcurrency, unknown)
+	assert.Less(t, small, large)
+	_, concurrency := f.uploadPartSize(o, 1, maxUploadParts)
+	assert.Equal(t, 1, concurrency)
+
+	// a number is used whatever the size
+	f, _ = newTestFs(t, "bucket", nil, configmap.Simple{"upload_concurrency": "4"})
+	for _, size := range []int64{100 * MiB, 10 * 1024 * MiB, -1} {
+		_, concurrency := f.uploadPartSize(o, size, maxUploadParts)
+		assert.Equal(t, 4, concurrency, size)
+	}
+}
+
+func TestCheckUploadMemory(t *testing.T) {
+	oldTotalMemory := totalMemory
+	totalMemory = func() int64 { return 1 << 30 }
+	var logs bytes.Buffer
+	oldLogPrint := fs.LogPrint
+	fs.LogPrint = func(level fs.LogLevel, text string) {
+		logs.WriteString(text + "\n")
+	}
+	defer func() {
+		totalMemory = oldTotalMemory
+		fs.LogPrint = oldLogPrint
+	}()
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	// the defaults buffer 5 MiB * 10 * 4 transfers
+	_, _ = newTestFs(t, "bucket", handler, nil)
+	assert.Empty(t, logs.String())
+
+	_, _ = newTestFs(t, "bucket", handler, configmap.Simple{"upload_concurrency": "1000"})
+	assert.Contains(t, logs.String(), "Multipart uploads may buffer 19.531Gi for 4 transfers (chunk_size 5Mi * upload_concurrency 1000 * --transfers) which is more than a quarter of the 1Gi of memory")
+}
+
+func TestUploadMultipartReusesBuffers(t *testing.T) {
+	store := newMemoryStore()
+	f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{
+		"no_check_bucket":    "true",
+		"chunk_size":         "1k",
+		"upload_cutoff":      "1k",
+		"upload_concurrency": "2",
+	})
+	for i := 0; i < 3; i++ {
+		contents := random.String(4500)
+		putTestObject(t, f, "file.txt", []byte(contents))
+		assert.Equal(t, contents, string(store.objects["file.txt"]))
+		// the 5 parts of each upload share at most upload_concurrency buffers
+		assert.Equal(t, 0, f.pool.InUse())
+		assert.LessOrEqual(t, f.pool.Alloced(), 2)
+	}
+}
+
+func TestChunkTimeout(t *testing.T) {
+	store := newMemoryStore()
+	var (
+		mu       sync.Mutex
+		attempts = map[string]int{}
+	)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/u/") {
+			partNum := r.URL.Query().Get("uploadPartNum")
+			mu.Lock()
+			attempts[partNum]++
+			hang := partNum == "2" && attempts[partNum] == 1
+			mu.Unlock()
+			if hang {
+				// the first attempt at part 2 hangs until the client
+				// gives up, which is only noticed once the body is read
+				_, _ = io.Copy(io.Discard, r.Body)
+				<-r.Context().Done()
+				return
+			}
+		}
+		store.handler(w, r)
+	}
+	f, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+		"no_check_bucket":    "true",
+		"chunk_size":         "1k",
+		"upload_cutoff":      "1k",
+		"upload_concurrency": "2",
+		"chunk_timeout":      "100ms",
+	})
+	f.pacer.SetRetries(2)
+	contents := []byte(random.String(4500))
+	start := time.Now()
+	putTestObject(t, f, "file.txt", contents)
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.Equal(t, contents, store.objects["file.txt"])
+	// only the stuck part was uploaded again
+	assert.Equal(t, map[string]int{"1": 1, "2": 2, "3": 1, "4": 1, "5": 1}, attempts)
+}
+
+// blockingReader signals started on its first Read then blocks until
+// release is closed and returns an error
+type blockingReader struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	close(r.started)
+	<-r.release
+	return 0, errors.New("interrupted")
+}
+
+func TestShutdownAbortsUploads(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name        string
+		leave       bool
+		wantAborted int
+	}{
+		{name: "Abort", wantAborted: 1},
+		{name: "LeavePartsOnError", leave: true, wantAborted: 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			store := newMemoryStore()
+			f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{
+				"no_check_bucket":      "true",
+				"chunk_size":           "1k",
+				"upload_cutoff":        "1k",
+				"leave_parts_on_error": fmt.Sprint(test.leave),
+			})
+			blocked := &blockingReader{started: make(chan struct{}), release: make(chan struct{})}
+			in := io.MultiReader(strings.NewReader(random.String(1024)), blocked)
+			src := object.NewStaticObjectInfo("file.txt", time.Now(), 4096, true, nil, f)
+			errc := make(chan error, 1)
+			go func() {
+				_, err := f.Put(ctx, in, src)
+				errc <- err
+			}()
+
+			// shut down while the upload is reading its second part
+			<-blocked.started
+			require.NoError(t, f.Shutdown(ctx))
+			store.mu.Lock()
+			assert.Equal(t, test.wantAborted, store.aborted)
+			store.mu.Unlock()
+
+			// the upload failing afterwards doesn't abort it again
+			close(blocked.release)
+			require.Error(t, <-errc)
+			assert.Equal(t, test.wantAborted, store.aborted)
+			assert.Empty(t, f.uploads)
+		})
+	}
+}
+
+func TestLeavePartsOnErrorOverride(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		override    string
+		wantAborted int
+		wantParts   int
+	}{
+		{override: "false", wantAborted: 1, wantParts: 0},
+		{override: "true", wantAborted: 0, wantParts: 1},
+	} {
+		t.Run(test.override, func(t *testing.T) {
+			// the option is set for the run like --oos-leave-parts-on-error
+			t.Setenv("RCLONE_OOS_LEAVE_PARTS_ON_ERROR", test.override)
+			store := newMemoryStore()
+			f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{
+				"no_check_bucket": "true",
+				"chunk_size":      "1k",
+				"upload_cutoff":   "1k",
+			})
+			in := io.MultiReader(strings.NewReader(random.String(1024)), iotest.ErrReader(errors.New("read failed")))
+			src := object.NewStaticObjectInfo("file.txt", time.Now(), 4096, true, nil, f)
+			_, err := f.Put(ctx, in, src)
+			assert.ErrorContains(t, err, "read failed")
+			assert.Equal(t, test.wantAborted, store.aborted)
+			assert.Len(t, store.parts, test.wantParts)
+		})
+	}
+}
+
+func BenchmarkUploadMultipart(b *testing.B) {
+	store := newMemoryStore()
+	f, _ := newTestFs(b, "bucket", store.handler, configmap.Simple{
+		"no_check_bucket": "true",
+		"chunk_size":      "64k",
+		"upload_cutoff":   "64k",
+	})
+	contents := []byte(random.String(1024 * 1024))
+	src := object.NewStaticObjectInfo("file.txt", time.Now(), int64(len(contents)), true, nil, f)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := f.Put(context.Background(), bytes.NewReader(contents), src)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestUploadMultipartVerifiesMD5(t *testing.T) {
+	ctx := context.Background()
+	contents := random.String(2500)
+	sum := md5.Sum([]byte(contents))
+	for _, test := range []struct {
+		name      string
+		corrupt   int
+		md5       string
+		leave     bool
+		wantErr   string
+		wantExist bool
+	}{
+		{name: "OK", md5: hex.EncodeToString(sum[:]), wantExist: true},
+		{name: "CorruptPart", corrupt: 2, wantErr: "multipart md5"},
+		{name: "CorruptPartLeave", corrupt: 2, leave: true, wantErr: "multipart md5", wantExist: true},
+		{name: "SourceMismatch", md5: "00112233445566778899aabbccddeeff", wantErr: "doesn't match source"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			store := newMemoryStore()
+			store.corrupt = test.corrupt
+			f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{
+				"no_check_bucket":      "true",
+				"chunk_size":           "1k",
+				"upload_cutoff":        "1k",
+				"leave_parts_on_error": fmt.Sprint(test.leave),
+			})
+			src := object.NewStaticObjectInfo("file.txt", time.Now(), int64(len(contents)), true,
+				map[hash.Type]string{hash.MD5: test.md5}, f)
+			_, err := f.Put(ctx, strings.NewReader(contents), src)
+			if test.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.wantErr)
+			}
+			_, exists := store.objects["file.txt"]
+			assert.Equal(t, test.wantExist, exists)
+			// committed uploads aren't aborted
+			assert.Equal(t, 0, store.aborted)
+		})
+	}
+}
+
+func TestCheckMultipartMD5(t *testing.T) {
+	part1, part2 := md5.Sum([]byte("part1")), md5.Sum([]byte("part2"))
+	partMD5s := append(part1[:], part2[:]...)
+	objectMD5 := md5.Sum([]byte("part1part2"))
+	hashOfHashes := md5.Sum(partMD5s)
+	multipartMD5 := base64.StdEncoding.EncodeToString(hashOfHashes[:])
+	for _, test := range []struct {
+		name    string
+		header  *string
+		wantErr string
+	}{
+		{name: "NoHeader"},
+		{name: "Suffixed", header: common.String(multipartMD5 + "-2")},
+		{name: "Unsuffixed", header: common.String(multipartMD5), wantErr: "multipart md5"},
+		{name: "WrongPartCount", header: common.String(multipartMD5 + "-3"), wantErr: "multipart md5"},
+		{name: "WrongMD5", header: common.String("XUFAKrxLKna5cZ2REBfFkg==-2"), wantErr: "multipart md5"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkMultipartMD5("", objectMD5[:], partMD5s, test.header)
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, errMultipartCorrupted)
+				assert.ErrorContains(t, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestAttemptResumeOnChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	contents := random.String(2500)
+	for _, test := range []struct {
+		name        string
+		attempt     bool
+		stream      bool
+		healed      int
+		wantCommits int
+		wantErr     string
+	}{
+		{name: "Off", healed: 1, wantCommits: 1, wantErr: "multipart md5"},
+		{name: "RetrySucceeds", attempt: true, healed: 1, wantCommits: 2},
+		{name: "RetryFails", attempt: true, wantCommits: 2, wantErr: "multipart md5"},
+		{name: "Stream", attempt: true, stream: true, healed: 1, wantCommits: 1, wantErr: "multipart md5"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			store := newMemoryStore()
+			store.corrupt = 2
+			store.healed = test.healed
+			f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{
+				"no_check_bucket":                     "true",
+				"chunk_size":                          "1k",
+				"upload_cutoff":                       "1k",
+				"attempt_resume_on_checksum_mismatch": fmt.Sprint(test.attempt),
+			})
+			var err error
+			if test.stream {
+				// streams can't be read again
+				src := object.NewStaticObjectInfo("file.txt", time.Now(), int64(len(contents)), true, nil, f)
+				_, err = f.Put(ctx, strings.NewReader(contents), src)
+			} else {
+				// copies read the source through the accounting
+				// which can't be rewound so it is opened again
+				srcFs, newErr := fs.NewFs(ctx, ":memory:"+t.Name())
+				require.NoError(t, newErr)
+				srcObj, putErr := srcFs.Put(ctx, strings.NewReader(contents), object.NewStaticObjectInfo("file.txt", time.Now(), int64(len(contents)), true, nil, nil))
+				require.NoError(t, putErr)
+				_, err = operations.Copy(ctx, f, nil, "file.txt", srcObj)
+			}
+			if test.wantErr == "" {
+				require.NoError(t, err)
+				assert.Equal(t, []byte(contents), store.objects["file.txt"])
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.wantErr)
+			}
+			assert.Equal(t, test.wantCommits, store.commits)
+		})
+	}
+}
+
+func TestCRC32C(t *testing.T) {
+	ctx := context.Background()
+	contents := []byte(random.String(2500))
+	hasher := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	_, _ = hasher.Write(contents)
+	crc := hex.EncodeToString(hasher.Sum(nil))
+	for _, test := range []struct {
+		name        string
+		config      configmap.Simple
+		wantCRC32Cs int
+		wantHash    string
+	}{
+		{name: "SinglePart", wantCRC32Cs: 1, wantHash: crc},
+		{name: "Multipart", config: configmap.Simple{"upload_cutoff": "1k"}, wantCRC32Cs: 3, wantHash: crc},
+		{name: "Disabled", config: configmap.Simple{"disable_crc32c": "true"}, wantCRC32Cs: 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			store := newMemoryStore()
+			config := configmap.Simple{"no_check_bucket": "true", "chunk_size": "1k"}
+			for k, v := range test.config {
+				config[k] = v
+			}
+			f, _ := newTestFs(t, "bucket", store.handler, config)
+			src := object.NewStaticObjectInfo("file.txt", time.Now(), int64(len(contents)), true,
+				map[hash.Type]string{CRC32CHashType: crc}, f)
+			o, err := f.Put(ctx, bytes.NewReader(contents), src)
+			require.NoError(t, err)
+			assert.Equal(t, test.wantCRC32Cs, store.crc32cs)
+			got, err := o.Hash(ctx, CRC32CHashType)
+			if test.wantHash == "" {
+				assert.ErrorIs(t, err, hash.ErrUnsupported)
+				assert.False(t, f.Hashes().Contains(CRC32CHashType))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.wantHash, got)
+				assert.True(t, f.Hashes().Contains(CRC32CHashType))
+			}
+		})
+	}
+}
+
+func TestPutContentMD5(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	f, ts := newTestFs(t, "bucket", store.handler, configmap.Simple{
+		"no_check_bucket": "true",
+	})
+
+	// the MD5 of sources without one is calculated
+	putTestObject(t, f, "seekable.txt", []byte("hello"))
+	assert.Equal(t, 1, store.md5s)
+	src := object.NewStaticObjectInfo("stream.txt", time.Now(), 5, true, nil, f)
+	_, err := f.Put(ctx, io.MultiReader(strings.NewReader("hello")), src)
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.md5s)
+
+	// a mismatch is retried if the upload can be sent again
+	f.pacer.SetRetries(2)
+	store.garbles = 1
+	puts := ts.count(http.MethodPut)
+	_, err = f.Put(ctx, io.MultiReader(strings.NewReader("world")), src)
+	require.NoError(t, err)
+	assert.Equal(t, puts+2, ts.count(http.MethodPut))
+	assert.Equal(t, []byte("world"), store.objects["stream.txt"])
+
+	// and fails if it keeps on happening
+	store.garbles = 2
+	_, err = f.Put(ctx, io.MultiReader(strings.NewReader("again")), src)
+	assert.ErrorContains(t, err, "does not match the Content-MD5 header")
+	assert.Equal(t, []byte("world"), store.objects["stream.txt"])
+
+	// no Content-MD5 is sent with disable_checksum
+	f.opt.DisableChecksum = true
+	md5s := store.md5s
+	putTestObject(t, f, "unchecked.txt", []byte("hello"))
+	assert.Equal(t, md5s, store.md5s)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	assert.Equal(t, []byte("hello"), store.objects["unchecked.txt"])
+}
+
+func TestPutObjectRetryRewinds(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	var fails int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/o/") && atomic.AddInt32(&fails, -1) >= 0 {
+			// fail after some of the body has been read
+			_, _ = io.ReadFull(r.Body, make([]byte, 2))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		store.handler(w, r)
+	}
+	f, ts := newTestFs(t, "bucket", handler, configmap.Simple{
+		"no_check_bucket":  "true",
+		"disable_checksum": "true",
+	})
+	f.pacer.SetRetries(2)
+
+	// a seekable upload is sent again from the start
+	atomic.StoreInt32(&fails, 1)
+	putTestObject(t, f, "seekable.txt", []byte("hello"))
+	assert.Equal(t, 2, ts.count(http.MethodPut))
+	assert.Equal(t, []byte("hello"), store.objects["seekable.txt"])
+
+	// a copy reads the source through the accounting, which can't be
+	// rewound, so the source is opened again
+	srcFs, err := fs.NewFs(ctx, ":memory:"+t.Name())
+	require.NoError(t, err)
+	srcObj, err := srcFs.Put(ctx, strings.NewReader("copied"), object.NewStaticObjectInfo("copy.txt", time.Now(), 6, true, nil, nil))
+	require.NoError(t, err)
+	atomic.StoreInt32(&fails, 1)
+	_, err = operations.Copy(ctx, f, nil, "copy.txt", srcObj)
+	require.NoError(t, err)
+	assert.Equal(t, 4, ts.count(http.MethodPut))
+	assert.Equal(t, []byte("copied"), store.objects["copy.txt"])
+
+	// but a stream can't be so it isn't retried
+	atomic.StoreInt32(&fails, 1)
+	src := object.NewStaticObjectInfo("stream.txt", time.Now(), 5, true, nil, f)
+	_, err = f.Put(ctx, io.MultiReader(strings.NewReader("world")), src)
+	assert.Error(t, err)
+	assert.Equal(t, 5, ts.count(http.MethodPut))
+	assert.NotContains(t, store.objects, "stream.txt")
+}
+
+func TestListHashes(t *testing.T) {
+	ctx := context.Background()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/o" {
+			_, _ = w.Write([]byte(`{"objects":[` +
+				`{"name":"single.txt","size":5,"md5":"XUFAKrxLKna5cZ2REBfFkg==","timeModified":"2020-01-01T00:00:00Z"},` +
+				`{"name":"multi.txt","size":5,"md5":"XUFAKrxLKna5cZ2REBfFkg==-3","timeModified":"2020-01-01T00:00:00Z"}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}
+	f, ts := newTestFs(t, "bucket", handler, nil)
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	hashes := map[string]string{}
+	for _, entry := range entries {
+		md5, err := entry.(fs.Object).Hash(ctx, hash.MD5)
+		require.NoError(t, err)
+		hashes[entry.Remote()] = md5
+	}
+	assert.Equal(t, map[string]string{
+		"single.txt": "5d41402abc4b2a76b9719d911017c592",
+		"multi.txt":  "",
+	}, hashes)
+	assert.Equal(t, 0, ts.count(http.MethodHead))
+}
+
+func TestSSEKMSKeyID(t *testing.T) {
+	const keyA = "ocid1.key.oc1.iad.vault.keya"
+	const keyB = "ocid1.key.oc1.iad.vault.keyb"
+	for _, test := range []struct {
+		name          string
+		key           string
+		bucketKey     string
+		wantGetBucket int
+		wantHeader    string
+	}{
+		{name: "NoKey", bucketKey: keyA},
+		{name: "NoBucketDefault", key: keyA, wantGetBucket: 1, wantHeader: keyA},
+		{name: "SameAsBucketDefault", key: keyA, bucketKey: keyA, wantGetBucket: 1},
+		{name: "DifferentToBucketDefault", key: keyB, bucketKey: keyA, wantGetBucket: 1, wantHeader: keyB},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var getBuckets int
+			var header string
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket":
+					getBuckets++
+					bucket := map[string]interface{}{"name": "bucket", "namespace": "testns"}
+					if test.bucketKey != "" {
+						bucket["kmsKeyId"] = test.bucketKey
+					}
+					_ = json.NewEncoder(w).Encode(bucket)
+				case r.Method == http.MethodPut && r.URL.Path == "/n/testns/b/bucket/o/file.txt":
+					header = r.Header.Get("opc-sse-kms-key-id")
+				case r.Method == http.MethodHead && r.URL.Path == "/n/testns/b/bucket/o/file.txt":
+					w.Header().Set("Content-Length", "5")
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}
+			f, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+				"no_check_bucket": "true",
+				"sse_kms_key_id":  test.key,
+			})
+			putTestObject(t, f, "file.txt", []byte("hello"))
+			assert.Equal(t, test.wantGetBucket, getBuckets)
+			assert.Equal(t, test.wantHeader, header)
+		})
+	}
+}
+
+func TestCheckReplication(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		logs []string
+	)
+	oldLogPrint := fs.LogPrint
+	fs.LogPrint = func(level fs.LogLevel, text string) {
+		if strings.Contains(text, "replication policy") {
+			mu.Lock()
+			logs = append(logs, text)
+			mu.Unlock()
+		}
+	}
+	defer func() {
+		fs.LogPrint = oldLogPrint
+	}()
+	for _, test := range []struct {
+		name          string
+		check         bool
+		bucket        map[string]interface{}
+		wantGetBucket int
+		wantLog       string
+	}{
+		{name: "Off", bucket: map[string]interface{}{"replicationEnabled": true}},
+		{name: "NotReplicated", check: true, bucket: map[string]interface{}{"replicationEnabled": false}, wantGetBucket: 1},
+		{name: "Source", check: true, bucket: map[string]interface{}{"replicationEnabled": true}, wantGetBucket: 1,
+			wantLog: `Bucket "bucket" is the source of a replication policy: objects deleted from it, eg by sync --delete, are deleted from its replicas too`},
+		{name: "Destination", check: true, bucket: map[string]interface{}{"isReadOnly": true}, wantGetBucket: 1,
+			wantLog: `Bucket "bucket" is the destination of a replication policy so it is read only: uploads and deletes will fail`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			logs = nil
+			var getBuckets int
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket" {
+					getBuckets++
+					test.bucket["name"] = "bucket"
+					test.bucket["namespace"] = "testns"
+					_ = json.NewEncoder(w).Encode(test.bucket)
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+			}
+			f, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+				"check_replication": fmt.Sprint(test.check),
+			})
+			assert.Equal(t, test.wantGetBucket, getBuckets)
+			if test.wantLog == "" {
+				assert.Empty(t, logs)
+			} else {
+				assert.Equal(t, []string{fmt.Sprintf("%v: %s", f, test.wantLog)}, logs)
+			}
+		})
+	}
+}
+
+func TestSSECustomerKeyFile(t *testing.T) {
+	ctx := context.Background()
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "sse.key")
+	require.NoError(t, os.WriteFile(keyFile, key, 0600))
+	sum := sha256.Sum256(key)
+	wantKey := base64.StdEncoding.EncodeToString(key)
+	wantSha256 := base64.StdEncoding.EncodeToString(sum[:])
+
+	store := newMemoryStore()
+	var (
+		mu      sync.Mutex
+		headers = map[string]http.Header{}
+	)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers[r.Method+" "+path.Base(r.URL.Path)] = r.Header.Clone()
+		mu.Unlock()
+		store.handler(w, r)
+	}
+	f, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+		"no_check_bucket":       "true",
+		"sse_customer_key_file": keyFile,
+	})
+	o := putTestObject(t, f, "file.txt", []byte("hello"))
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	_, err = io.Copy(io.Discard, in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	_, err = f.Copy(ctx, o, "copy.txt")
+	require.NoError(t, err)
+
+	for _, request := range []string{"PUT file.txt", "HEAD file.txt", "GET file.txt", "POST copyObject", "HEAD copy.txt"} {
+		header := headers[request]
+		require.NotNil(t, header, request)
+		assert.Equal(t, "AES256", header.Get("opc-sse-customer-algorithm"), request)
+		assert.Equal(t, wantKey, header.Get("opc-sse-customer-key"), request)
+		assert.Equal(t, wantSha256, header.Get("opc-sse-customer-key-sha256"), request)
+	}
+	copyHeader := headers["POST copyObject"]
+	assert.Equal(t, "AES256", copyHeader.Get("opc-source-sse-customer-algorithm"))
+	assert.Equal(t, wantKey, copyHeader.Get("opc-source-sse-customer-key"))
+	assert.Equal(t, wantSha256, copyHeader.Get("opc-source-sse-customer-key-sha256"))
+
+	// the key must be exactly 32 bytes
+	shortFile := filepath.Join(dir, "short.key")
+	require.NoError(t, os.WriteFile(shortFile, key[:31], 0600))
+	_, err = readSSECustomerKeyFile(shortFile)
+	assert.ErrorContains(t, err, "must contain a 32 byte key, got 31 bytes")
+	_, err = readSSECustomerKeyFile(filepath.Join(dir, "missing.key"))
+	assert.ErrorContains(t, err, "failed to read sse_customer_key_file")
+
+	regInfo, err := fs.Find("oracleobjectstorage")
+	require.NoError(t, err)
+	_, err = NewFs(ctx, "TestOOS", "bucket", fs.ConfigMap(regInfo, "TestOOS", configmap.Simple{
+		"provider":              noAuth,
+		"namespace":             "testns",
+		"sse_customer_key_file": keyFile,
+		"sse_kms_key_id":        "ocid1.key.oc1.iad.vault.keya",
+	}))
+	assert.ErrorContains(t, err, "can't both be set")
+}
+
+func TestObjectHead(t *testing.T) {
+	ctx := context.Background()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead || r.URL.Path != "/n/testns/b/bucket/o/dir/file.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		h := w.Header()
+		h.Set("Content-Length", "5")
+		h.Set("ETag", "etag-1")
+		h.Set("Content-MD5", "XUFAKrxLKna5cZ2REBfFkg==")
+		h.Set(crc32cHeader, "mnG7TA==")
+		h.Set("Content-Type", "text/plain")
+		h.Set("Content-Encoding", "gzip")
+		h.Set("Content-Language", "en")
+		h.Set("Cache-Control", "max-age=3600")
+		h.Set("Last-Modified", "Sat, 03 Feb 2001 04:05:06 GMT")
+		h.Set("storage-tier", "Archive")
+		h.Set("archival-state", "Restored")
+		h.Set("version-id", "v1")
+		h.Set("opc-meta-mtime", "981173106")
+		h.Set("opc-meta-owner", "alice")
+	}
+	f, ts := newTestFs(t, "bucket", handler, nil)
+	got, err := f.Command(ctx, "object-head", []string{"dir/file.txt"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ts.count(http.MethodHead))
+	out, err := json.Marshal(got)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "dir/file.txt",
+		"size": 5,
+		"etag": "etag-1",
+		"contentMd5": "XUFAKrxLKna5cZ2REBfFkg==",
+		"crc32c": "mnG7TA==",
+		"contentType": "text/plain",
+		"contentEncoding": "gzip",
+		"contentLanguage": "en",
+		"cacheControl": "max-age=3600",
+		"storageTier": "Archive",
+		"archivalState": "Restored",
+		"versionId": "v1",
+		"lastModified": "2001-02-03T04:05:06Z",
+		"metadata": {
+			"opc-meta-mtime": "981173106",
+			"opc-meta-owner": "alice"
+		}
+	}`, string(out))
+
+	_, err = f.Command(ctx, "object-head", []string{"missing.txt"}, nil)
+	assert.Error(t, err)
+	_, err = f.Command(ctx, "object-head", nil, nil)
+	assert.ErrorContains(t, err, "object-head needs an object")
+}
+
+func TestNewObjectErrors(t *testing.T) {
+	ctx := context.Background()
+	for _, noHeadObject := range []bool{false, true} {
+		for _, test := range []struct {
+			status       int
+			wantNotFound bool
+			wantDenied   bool
+		}{
+			{status: http.StatusNotFound, wantNotFound: true},
+			{status: http.StatusForbidden, wantDenied: true},
+			{status: http.StatusUnauthorized, wantDenied: true},
+			{status: http.StatusBadRequest},
+		} {
+			t.Run(fmt.Sprintf("NoHeadObject=%v/%d", noHeadObject, test.status), func(t *testing.T) {
+				handler := func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(test.status)
+				}
+				f, _ := newTestFs(t, "bucket", handler, configmap.Simple{
+					"no_head_object": fmt.Sprint(noHeadObject),
+				})
+				_, err := f.NewObject(ctx, "file.txt")
+				require.Error(t, err)
+				assert.Equal(t, test.wantNotFound, errors.Is(err, fs.ErrorObjectNotFound), err)
+				assert.Equal(t, test.wantDenied, errors.Is(err, fs.ErrorPermissionDenied), err)
+				if test.wantDenied {
+					assert.Contains(t, err.Error(), `not authorized to read "file.txt" in bucket "bucket"`)
+				}
+			})
+		}
+	}
+}
+
+func TestListChunk(t *testing.T) {
+	ctx := context.Background()
+	var limits []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/o" {
+			limits = append(limits, r.URL.Query().Get("limit"))
+			if r.URL.Query().Get("start") == "" {
+				_, _ = w.Write([]byte(`{"objects":[{"name":"a.txt","size":1},{"name":"b.txt","size":1}],"nextStartWith":"c.txt"}`))
+			} else {
+				_, _ = w.Write([]byte(`{"objects":[{"name":"c.txt","size":1}]}`))
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}
+	f, _ := newTestFs(t, "bucket", handler, nil)
+	_, err := f.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1000", "1000"}, limits)
+
+	f, _ = newTestFs(t, "bucket", handler, configmap.Simple{"list_chunk": "2"})
+	limits = nil
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, []string{"2", "2"}, limits)
+}
+
+func TestListFields(t *testing.T) {
+	ctx := context.Background()
+	var fields string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/o":
+			// return only the fields asked for
+			fields = r.URL.Query().Get("fields")
+			item := map[string]interface{}{"name": "file.txt", "size": 5}
+			all := map[string]interface{}{
+				"etag":         "list-etag",
+				"md5":          "XUFAKrxLKna5cZ2REBfFkg==",
+				"timeModified": "2020-01-01T00:00:00Z",
+				"storageTier":  "InfrequentAccess",
+			}
+			for _, field := range strings.Split(fields, ",") {
+				if v, ok := all[field]; ok {
+					item[field] = v
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"objects": []interface{}{item}})
+		case r.Method == http.MethodHead && r.URL.Path == "/n/testns/b/bucket/o/file.txt":
+			w.Header().Set("Content-Length", "5")
+			w.Header().Set("storage-tier", "Archive")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	for _, test := range []struct {
+		name       string
+		listFields string
+		wantFields string
+		wantTier   string
+		wantETag   string
+		wantHeads  int
+	}{
+		{name: "Default", wantFields: listFields, wantTier: "infrequentaccess", wantETag: "list-etag"},
+		{name: "Trimmed", listFields: "md5", wantFields: "name,size,md5", wantTier: "archive", wantHeads: 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			config := configmap.Simple{}
+			if test.listFields != "" {
+				config["list_fields"] = test.listFields
+			}
+			f, ts := newTestFs(t, "bucket", handler, config)
+			entries, err := f.List(ctx, "")
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			assert.Equal(t, test.wantFields, fields)
+			o := entries[0].(*Object)
+			md5, err := o.Hash(ctx, hash.MD5)
+			require.NoError(t, err)
+			assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", md5)
+			assert.Equal(t, test.wantETag, o.ID())
+			assert.Equal(t, test.wantTier, o.GetTier())
+			assert.Equal(t, test.wantHeads, ts.count(http.MethodHead))
+		})
+	}
+}
+
+func TestCheckUsesListing(t *testing.T) {
+	files := map[string]string{
+		"a.txt":         "hello",
+		"dir/b.txt":     "world",
+		"dir/sub/c.txt": "rclone",
+		"multi.bin":     "multipart",
+	}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/n/testns/b/bucket/o" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		prefix, delimiter := r.URL.Query().Get("prefix"), r.URL.Query().Get("delimiter")
+		var items []map[string]interface{}
+		prefixes := map[string]bool{}
+		for name, contents := range files {
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			if i := strings.Index(name[len(prefix):], "/"); delimiter != "" && i >= 0 {
+				prefixes[name[:len(prefix)+i+1]] = true
+				continue
+			}
+			sum := md5.Sum([]byte(contents))
+			md5 := base64.StdEncoding.EncodeToString(sum[:])
+			if name == "multi.bin" {
+				md5 += "-2"
+			}
+			items = append(items, map[string]interface{}{"name": name, "size": len(contents), "md5": md5, "timeModified": "2020-01-01T00:00:00Z"})
+		}
+		var prefixList []string
+		for prefix := range prefixes {
+			prefixList = append(prefixList, prefix)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"objects": items, "prefixes": prefixList})
+	}
+	for _, fastList := range []bool{false, true} {
+		t.Run(fmt.Sprintf("FastList=%v", fastList), func(t *testing.T) {
+			ctx, ci := fs.AddConfig(context.Background())
+			ci.UseListR = fastList
+			src, err := fs.NewFs(ctx, ":memory:src")
+			require.NoError(t, err)
+			for name, contents := range files {
+				info := object.NewStaticObjectInfo(name, time.Now(), int64(len(contents)), true, nil, src)
+				_, err = src.Put(ctx, strings.NewReader(contents), info)
+				require.NoError(t, err)
+			}
+			f, ts := newTestFs(t, "bucket", handler, nil)
+			ts.requests = nil
+			err = operations.Check(ctx, &operations.CheckOpt{Fdst: f, Fsrc: src})
+			require.NoError(t, err)
+			// sizes and hashes come from the listing without a HEAD per object
+			assert.Equal(t, 0, ts.count(http.MethodHead))
+			if fastList {
+				assert.Equal(t, []string{"GET /n/testns/b/bucket/o"}, ts.requests)
+			} else {
+				assert.Equal(t, 3, ts.count(http.MethodGet)) // one for each directory
+			}
+		})
+	}
+}
+
+func TestETag(t *testing.T) {
+	ctx := context.Background()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/o":
+			_, _ = w.Write([]byte(`{"objects":[` +
+				`{"name":"file.txt","size":5,"etag":"list-etag","md5":"XUFAKrxLKna5cZ2REBfFkg==-3","timeModified":"2020-01-01T00:00:00Z"}]}`))
+		case r.Method == http.MethodHead && r.URL.Path == "/n/testns/b/bucket/o/file.txt":
+			w.Header().Set("Content-Length", "5")
+			w.Header().Set("ETag", "head-etag")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	f, ts := newTestFs(t, "bucket", handler, nil)
+
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	o := entries[0].(*Object)
+	assert.Equal(t, "list-etag", o.ETag())
+	assert.Equal(t, "list-etag", o.ID())
+	assert.Equal(t, 0, ts.count(http.MethodHead))
+
+	obj, err := f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "head-etag", obj.(*Object).ETag())
+	assert.Equal(t, "head-etag", obj.(fs.IDer).ID())
+}
+
+func TestUploadStorageTierMetadata(t *testing.T) {
+	ctx, ci := fs.AddConfig(context.Background())
+	ci.Metadata = true
+	var tiers []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			tiers = append(tiers, r.Header.Get("storage-tier"))
+		}
+		w.Header().Set("Content-Length", "5")
+	}
+	f, ts := newTestFs(t, "bucket", handler, configmap.Simple{
+		"no_check_bucket": "true",
+		"storage_tier":    "Standard",
+	})
+	src := object.NewStaticObjectInfo("file.txt", time.Now(), 5, true, nil, f)
+	for _, test := range []struct {
+		options []fs.OpenOption
+		want    string
+	}{
+		{want: "Standard"},
+		{options: []fs.OpenOption{fs.MetadataOption{"tier": "Archive"}}, want: "Archive"},
+		{options: []fs.OpenOption{fs.MetadataOption{"storage-tier": "InfrequentAccess"}}, want: "InfrequentAccess"},
+	} {
+		tiers = nil
+		_, err := f.Put(ctx, strings.NewReader("hello"), src, test.options...)
+		require.NoError(t, err)
+		assert.Equal(t, []string{test.want}, tiers)
+	}
+
+	puts := ts.count(http.MethodPut)
+	_, err := f.Put(ctx, strings.NewReader("hello"), src, fs.MetadataOption{"tier": "Frozen"})
+	assert.EqualError(t, err, "not a valid storage tier: Frozen")
+	assert.Equal(t, puts, ts.count(http.MethodPut))
+}
+
+func TestBucketAutoTiering(t *testing.T) {
+	ctx := context.Background()
+	for _, tiering := range []string{"Disabled", "InfrequentAccess"} {
+		t.Run(tiering, func(t *testing.T) {
+			var details objectstorage.CreateBucketDetails
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodHead:
+					w.WriteHeader(http.StatusNotFound)
+				case r.Method == http.MethodPost && r.URL.Path == "/n/testns/b":
+					assert.NoError(t, json.NewDecoder(r.Body).Decode(&details))
+					_, _ = w.Write([]byte(`{"name":"bucket"}`))
+				case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket":
+					_, _ = fmt.Fprintf(w, `{"name":"bucket","autoTiering":%q}`, details.AutoTiering)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}
+			f, _ := newTestFs(t, "bucket", handler, configmap.Simple{"bucket_auto_tiering": tiering})
+			require.NoError(t, f.Mkdir(ctx, ""))
+			assert.Equal(t, objectstorage.BucketAutoTieringEnum(tiering), details.AutoTiering)
+			got, err := f.Command(ctx, "auto-tiering", nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tiering, got)
+		})
+	}
+
+	regInfo, err := fs.Find("oracleobjectstorage")
+	require.NoError(t, err)
+	_, err = NewFs(ctx, "TestOOS", "bucket", fs.ConfigMap(regInfo, "TestOOS", configmap.Simple{
+		"provider":            noAuth,
+		"bucket_auto_tiering": "Sometimes",
+	}))
+	assert.EqualError(t, err, "not a valid bucket auto tiering: Sometimes")
+}
+
+func TestReplication(t *testing.T) {
+	ctx := context.Background()
+	var created objectstorage.CreateReplicationPolicyDetails
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/replicationPolicies":
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("opc-next-page", "2")
+				_, _ = w.Write([]byte(`[{"id":"p1","name":"dr","destinationRegionName":"us-phoenix-1","destinationBucketName":"bucket-dr",` +
+					`"timeCreated":"2022-07-29T06:21:16.595Z","timeLastSync":"2022-07-30T06:21:16.595Z","status":"ACTIVE","statusMessage":""}]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[{"id":"p2","name":"dr2","destinationRegionName":"eu-frankfurt-1","destinationBucketName":"bucket-dr2",` +
+				`"timeCreated":"2022-07-29T06:21:16.595Z","timeLastSync":"2022-07-30T06:21:16.595Z","status":"CLIENT_ERROR","statusMessage":"no permission"}]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/n/testns/b/bucket/replicationPolicies":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+			_, _ = fmt.Fprintf(w, `{"id":"p3","name":%q,"destinationRegionName":%q,"destinationBucketName":%q,"status":"ACTIVE"}`,
+				*created.Name, *created.DestinationRegionName, *created.DestinationBucketName)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	f, ts := newTestFs(t, "bucket", handler, nil)
+
+	got, err := f.Command(ctx, "replication", nil, nil)
+	require.NoError(t, err)
+	policies := got.([]objectstorage.ReplicationPolicySummary)
+	require.Len(t, policies, 2)
+	assert.Equal(t, "dr", *policies[0].Name)
+	assert.Equal(t, "us-phoenix-1", *policies[0].DestinationRegionName)
+	assert.Equal(t, "bucket-dr", *policies[0].DestinationBucketName)
+	assert.Equal(t, objectstorage.ReplicationPolicySummaryStatusActive, policies[0].Status)
+	assert.Equal(t, time.Date(2022, 7, 30, 6, 21, 16, 595000000, time.UTC), policies[0].TimeLastSync.Time)
+	assert.Equal(t, objectstorage.ReplicationPolicySummaryStatusClientError, policies[1].Status)
+	assert.Equal(t, "no permission", *policies[1].StatusMessage)
+
+	// creating needs the destination and confirm
+	opt := map[string]string{"destination-region": "us-phoenix-1"}
+	_, err = f.Command(ctx, "replication", []string{"create"}, opt)
+	assert.ErrorContains(t, err, "needs -o destination-region=REGION -o destination-bucket=BUCKET")
+	opt["destination-bucket"] = "bucket-dr"
+	_, err = f.Command(ctx, "replication", []string{"create"}, opt)
+	assert.ErrorContains(t, err, "add -o confirm to create it")
+	assert.Equal(t, 0, ts.count(http.MethodPost))
+
+	opt["confirm"] = ""
+	got, err = f.Command(ctx, "replication", []string{"create"}, opt)
+	require.NoError(t, err)
+	assert.Equal(t, "p3", *got.(*objectstorage.ReplicationPolicy).Id)
+	assert.Equal(t, "bucket-to-us-phoenix-1-bucket-dr", *created.Name)
+	assert.Equal(t, 1, ts.count(http.MethodPost))
+
+	_, err = f.Command(ctx, "replication", []string{"delete"}, nil)
+	assert.EqualError(t, err, `unknown replication argument "delete", only create is supported`)
+}
+
+func TestBucketCreateDelete(t *testing.T) {
+	ctx := context.Background()
+	var created objectstorage.CreateBucketDetails
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && strings.TrimSuffix(r.URL.Path, "/") == "/n/testns/b":
+			created = objectstorage.CreateBucketDetails{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+			_, _ = fmt.Fprintf(w, `{"name":%q,"namespace":"testns","compartmentId":%q,"publicAccessType":%q,`+
+				`"storageTier":%q,"versioning":%q,"autoTiering":%q,"objectEventsEnabled":true}`,
+				*created.Name, *created.CompartmentId, created.PublicAccessType, created.StorageTier, created.Versioning, created.AutoTiering)
+		case r.Method == http.MethodDelete && r.URL.Path == "/n/testns/b/new-bucket":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	f, ts := newTestFs(t, "new-bucket", handler, configmap.Simple{"compartment": "ocid1.compartment.oc1..aaaaaaaa"})
+
+	_, err := f.Command(ctx, "bucket-create", nil, map[string]string{"public-access": "everyone"})
+	assert.ErrorContains(t, err, `bad public-access "everyone"`)
+	_, err = f.Command(ctx, "bucket-create", nil, map[string]string{"compartment": "bad"})
+	assert.ErrorContains(t, err, `not a valid compartment "bad"`)
+	assert.Equal(t, 0, ts.count(http.MethodPost))
+
+	got, err := f.Command(ctx, "bucket-create", nil, map[string]string{
+		"public-access": "objectread",
+		"storage-tier":  "Archive",
+		"auto-tiering":  "InfrequentAccess",
+		"versioning":    "",
+		"object-events": "",
+		"kms-key-id":    "ocid1.key.oc1..bbbbbbbb",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "new-bucket", *created.Name)
+	assert.Equal(t, "ocid1.compartment.oc1..aaaaaaaa", *created.CompartmentId)
+	assert.Equal(t, objectstorage.CreateBucketDetailsPublicAccessTypeObjectread, created.PublicAccessType)
+	assert.Equal(t, objectstorage.CreateBucketDetailsStorageTierArchive, created.StorageTier)
+	assert.Equal(t, objectstorage.BucketAutoTieringInfrequentaccess, created.AutoTiering)
+	assert.Equal(t, objectstorage.CreateBucketDetailsVersioningEnabled, created.Versioning)
+	require.NotNil(t, created.ObjectEventsEnabled)
+	assert.True(t, *created.ObjectEventsEnabled)
+	require.NotNil(t, created.KmsKeyId)
+	assert.Equal(t, "ocid1.key.oc1..bbbbbbbb", *created.KmsKeyId)
+	assert.Equal(t, &bucketInfo{
+		Name:             "new-bucket",
+		Namespace:        "testns",
+		CompartmentID:    "ocid1.compartment.oc1..aaaaaaaa",
+		PublicAccessType: "ObjectRead",
+		StorageTier:      "Archive",
+		Versioning:       "Enabled",
+		AutoTiering:      "InfrequentAccess",
+	}, got)
+
+	// the defaults make a private bucket like Mkdir does
+	_, err = f.Command(ctx, "bucket-create", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, objectstorage.CreateBucketDetailsPublicAccessTypeNopublicaccess, created.PublicAccessType)
+	assert.Equal(t, objectstorage.BucketAutoTieringDisabled, created.AutoTiering)
+	assert.Equal(t, objectstorage.CreateBucketDetailsVersioningEnum(""), created.Versioning)
+	assert.Nil(t, created.ObjectEventsEnabled)
+	assert.Nil(t, created.KmsKeyId)
+
+	_, err = f.Command(ctx, "bucket-delete", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ts.count(http.MethodDelete))
+
+	root, _ := newTestFs(t, "", handler, nil)
+	_, err = root.Command(ctx, "bucket-delete", nil, nil)
+	assert.EqualError(t, err, "bucket-delete needs a bucket, eg oos:bucket")
+}
+
+func TestParURL(t *testing.T) {
+	for _, test := range []struct {
+		host      string
+		accessURI string
+		want      string
+	}{
+		{"https://objectstorage.us-ashburn-1.oraclecloud.com", "/p/abc/n/ns/b/bucket/o/file.txt", "https://objectstorage.us-ashburn-1.oraclecloud.com/p/abc/n/ns/b/bucket/o/file.txt"},
+		{"https://objectstorage.us-ashburn-1.oraclecloud.com/", "/p/abc/n/ns/b/bucket/o/file.txt", "https://objectstorage.us-ashburn-1.oraclecloud.com/p/abc/n/ns/b/bucket/o/file.txt"},
+		{"objectstorage.us-ashburn-1.oraclecloud.com", "p/abc/n/ns/b/bucket/o/dir/file.txt", "https://objectstorage.us-ashburn-1.oraclecloud.com/p/abc/n/ns/b/bucket/o/dir/file.txt"},
+		{"http://127.0.0.1:8080", "/p/abc/n/ns/b/bucket/o/file.txt", "http://127.0.0.1:8080/p/abc/n/ns/b/bucket/o/file.txt"},
+	} {
+		assert.Equal(t, test.want, parURL(test.host, test.accessURI), test.host)
+	}
+}
+
+func TestParCreate(t *testing.T) {
+	ctx := context.Background()
+	var created objectstorage.CreatePreauthenticatedRequestDetails
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/n/testns/b/bucket/p" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		created = objectstorage.CreatePreauthenticatedRequestDetails{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+		_, _ = fmt.Fprintf(w, `{"id":"par1","name":%q,"accessUri":"/p/secret/n/testns/b/bucket/o/%s","accessType":%q,"objectName":%q,`+
+			`"timeCreated":"2020-01-01T00:00:00Z","timeExpires":%q}`,
+			*created.Name, *created.ObjectName, created.AccessType, *created.ObjectName, created.TimeExpires.Format(time.RFC3339Nano))
+	}
+	f, ts := newTestFs(t, "bucket/dir", handler, nil)
+
+	start := time.Now()
+	got, err := f.Command(ctx, "par-create", []string{"file.txt"}, map[string]string{"expire": "2h"})
+	require.NoError(t, err)
+	par := got.(*parResult)
+	assert.Equal(t, "par1", par.ID)
+	assert.Equal(t, "dir/file.txt", par.Object)
+	assert.Equal(t, "ObjectWrite", par.AccessType)
+	assert.Equal(t, strings.TrimSuffix(f.srv.Host, "/")+"/p/secret/n/testns/b/bucket/o/dir/file.txt", par.URL)
+	assert.WithinDuration(t, start.Add(2*time.Hour), par.Expires, time.Minute)
+	assert.Equal(t, "dir/file.txt", *created.ObjectName)
+	assert.True(t, strings.HasPrefix(*created.Name, "rclone-dir/file.txt-"), *created.Name)
+
+	// the access and expiry default to write for 24h
+	got, err = f.Command(ctx, "par-create", []string{"file.txt"}, map[string]string{"access": "Read", "name": "mine"})
+	require.NoError(t, err)
+	par = got.(*parResult)
+	assert.Equal(t, "ObjectRead", par.AccessType)
+	assert.Equal(t, "mine", par.Name)
+	assert.WithinDuration(t, time.Now().Add(defaultParExpiry), par.Expires, time.Minute)
+	assert.Equal(t, 2, ts.count(http.MethodPost))
+
+	for _, test := range []struct {
+		args    []string
+		opt     map[string]string
+		wantErr string
+	}{
+		{args: []string{"file.txt"}, opt: map[string]string{"access": "delete"}, wantErr: `unknown access "delete": must be one of read, readwrite, write`},
+		{args: []string{"file.txt"}, opt: map[string]string{"expire": "soon"}, wantErr: "bad expire"},
+		{args: []string{"file.txt"}, opt: map[string]string{"expire": "-1h"}, wantErr: "expire must be positive"},
+	} {
+		_, err := f.Command(ctx, "par-create", test.args, test.opt)
+		assert.ErrorContains(t, err, test.wantErr)
+	}
+	assert.Equal(t, 2, ts.count(http.MethodPost))
+
+	f, _ = newTestFs(t, "bucket", handler, nil)
+	_, err = f.Command(ctx, "par-create", nil, nil)
+	assert.ErrorContains(t, err, "par-create needs an object")
+}
+
+func TestParListDelete(t *testing.T) {
+	ctx := context.Background()
+	var deleted []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/p":
+			assert.Equal(t, "dir", r.URL.Query().Get("objectNamePrefix"))
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("opc-next-page", "page2")
+				_, _ = w.Write([]byte(`[{"id":"par1","name":"upload","objectName":"dir/file.txt","accessType":"ObjectWrite",` +
+					`"timeCreated":"2020-01-01T00:00:00Z","timeExpires":"2020-01-02T00:00:00Z"}]`))
+				return
+			}
+			assert.Equal(t, "page2", r.URL.Query().Get("page"))
+			_, _ = w.Write([]byte(`[{"id":"par2","name":"read","objectName":"dir/other.txt","accessType":"ObjectRead",` +
+				`"timeCreated":"2020-01-01T00:00:00Z","timeExpires":"2020-02-01T00:00:00Z"}]`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/n/testns/b/bucket/p/par1":
+			deleted = append(deleted, "par1")
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"NotFound","message":"not found"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	f, ts := newTestFs(t, "bucket/dir", handler, nil)
+
+	got, err := f.Command(ctx, "par-list", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []parResult{{
+		ID:         "par1",
+		Name:       "upload",
+		Object:     "dir/file.txt",
+		AccessType: "ObjectWrite",
+		Expires:    time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+	}, {
+		ID:         "par2",
+		Name:       "read",
+		Object:     "dir/other.txt",
+		AccessType: "ObjectRead",
+		Expires:    time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+	}}, got)
+	assert.Equal(t, 2, ts.count(http.MethodGet))
+
+	_, err = f.Command(ctx, "par-delete", nil, nil)
+	assert.ErrorContains(t, err, "par-delete needs the id of the PAR")
+	_, err = f.Command(ctx, "par-delete", nil, map[string]string{"id": "par1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"par1"}, deleted)
+	// deleting a PAR which doesn't exist succeeds
+	_, err = f.Command(ctx, "par-delete", nil, map[string]string{"id": "gone"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, ts.count(http.MethodDelete))
+}
+
+func TestUsageByTier(t *testing.T) {
+	ctx := context.Background()
+	var prefix string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/o" {
+			prefix = r.URL.Query().Get("prefix")
+			_, _ = w.Write([]byte(`{"objects":[` +
+				`{"name":"dir/a.txt","size":10,"storageTier":"Standard"},` +
+				`{"name":"dir/b.txt","size":20,"storageTier":"Standard"},` +
+				`{"name":"dir/c.txt","size":30,"storageTier":"InfrequentAccess"},` +
+				`{"name":"dir/d.txt","size":40,"storageTier":"Archive","archivalState":"Archived"},` +
+				`{"name":"dir/e.txt","size":50,"storageTier":"Archive","archivalState":"Restored"},` +
+				`{"name":"dir/sub/f.txt","size":60,"storageTier":"Archive","archivalState":"Archived"}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}
+	f, ts := newTestFs(t, "bucket/dir/", handler, nil)
+	got, err := f.Command(ctx, "usage-by-tier", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "dir/", prefix)
+	assert.Equal(t, []tierUsage{
+		{Tier: "Archive", ArchivalState: "Archived", Count: 2, Size: 100},
+		{Tier: "Archive", ArchivalState: "Restored", Count: 1, Size: 50},
+		{Tier: "InfrequentAccess", Count: 1, Size: 30},
+		{Tier: "Standard", Count: 2, Size: 30},
+	}, got)
+	assert.Equal(t, 0, ts.count(http.MethodHead))
+
+	f, _ = newTestFs(t, "bucket", handler, configmap.Simple{"list_fields": "md5"})
+	_, err = f.Command(ctx, "usage-by-tier", nil, nil)
+	assert.ErrorContains(t, err, "needs list_fields to include storageTier")
+}
+
+func TestDirMove(t *testing.T) {
+	ctx := context.Background()
+	var (
+		mu      sync.Mutex
+		objects map[string]bool
+		fail    string // rename of this object fails
+	)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket/o":
+			prefix := r.URL.Query().Get("prefix")
+			var names []string
+			for name := range objects {
+				if strings.HasPrefix(name, prefix) {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+			var items []map[string]interface{}
+			for _, name := range names {
+				items = append(items, map[string]interface{}{"name": name, "size": 1})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"objects": items})
+		case r.Method == http.MethodPost && r.URL.Path == "/n/testns/b/bucket/actions/renameObject":
+			var details objectstorage.RenameObjectDetails
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&details))
+			if *details.SourceName == fail || !objects[*details.SourceName] {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			delete(objects, *details.SourceName)
+			objects[*details.NewName] = true
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	f, _ := newTestFs(t, "bucket", handler, nil)
+
+	objects = map[string]bool{"dir/a.txt": true, "dir/b.txt": true, "dir/sub/c.txt": true, "dir2/d.txt": true, "other.txt": true}
+	require.NoError(t, f.DirMove(ctx, f, "dir", "moved"))
+	assert.Equal(t, map[string]bool{"moved/a.txt": true, "moved/b.txt": true, "moved/sub/c.txt": true, "dir2/d.txt": true, "other.txt": true}, objects)
+
+	// the destination must not exist
+	assert.ErrorIs(t, f.DirMove(ctx, f, "moved", "dir2"), fs.ErrorDirExists)
+	assert.ErrorIs(t, f.DirMove(ctx, f, "missing", "new"), fs.ErrorDirNotFound)
+
+	// a failure doesn't stop the other objects being moved
+	fail = "moved/b.txt"
+	err := f.DirMove(ctx, f, "moved", "again")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `failed to move 1 of 3 objects, including "moved/b.txt"`)
+	assert.Equal(t, map[string]bool{"again/a.txt": true, "moved/b.txt": true, "again/sub/c.txt": true, "dir2/d.txt": true, "other.txt": true}, objects)
+
+	// renames don't work across buckets
+	f, _ = newTestFs(t, "", handler, nil)
+	assert.ErrorIs(t, f.DirMove(ctx, f, "bucket/dir2", "bucket2/dir2"), fs.ErrorCantDirMove)
+}
+
+func TestBucketInfo(t *testing.T) {
+	ctx := context.Background()
+	var fields string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/n/testns/b/bucket" {
+			fields = r.URL.Query().Get("fields")
+			_, _ = w.Write([]byte(`{
+				"namespace": "testns",
+				"name": "bucket",
+				"compartmentId": "ocid1.compartment.oc1..aaaa",
+				"metadata": {},
+				"createdBy": "ocid1.user.oc1..bbbb",
+				"timeCreated": "2022-07-29T06:21:16.595Z",
+				"etag": "etag",
+				"publicAccessType": "ObjectRead",
+				"storageTier": "Standard",
+				"approximateCount": 1234,
+				"approximateSize": 56789012,
+				"replicationEnabled": true,
+				"isReadOnly": false,
+				"versioning": "Enabled"
+			}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}
+	f, _ := newTestFs(t, "bucket", handler, nil)
+	got, err := f.Command(ctx, "bucket-info", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &bucketInfo{
+		Name:               "bucket",
+		Namespace:          "testns",
+		CompartmentID:      "ocid1.compartment.oc1..aaaa",
+		PublicAccessType:   "ObjectRead",
+		StorageTier:        "Standard",
+		Versioning:         "Enabled",
+		AutoTiering:        "Disabled",
+		ApproximateCount:   1234,
+		ApproximateSize:    56789012,
+		ReplicationEnabled: true,
+	}, got)
+	assert.Equal(t, "approximateCount,approximateSize,autoTiering", fields)
+
+	f, _ = newTestFs(t, "", handler, nil)
+	_, err = f.Command(ctx, "bucket-info", nil, nil)
+	assert.EqualError(t, err, "bucket-info needs a bucket, eg oos:bucket")
+}
+
+func TestRename(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{"no_check_bucket": "true"})
+	putTestObject(t, f, "a.txt", []byte("hello"))
+	putTestObject(t, f, "b.txt", []byte("world"))
+	md5sum := store.meta["a.txt"].Get("Content-MD5")
+
+	_, err := f.Command(ctx, "rename", []string{"a.txt", "c.txt"}, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, store.objects, "a.txt")
+	assert.Equal(t, "hello", string(store.objects["c.txt"]))
+	o, err := f.NewObject(ctx, "c.txt")
+	require.NoError(t, err)
+	gotMD5, err := o.Hash(ctx, hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", gotMD5)
+	assert.Equal(t, md5sum, store.meta["c.txt"].Get("Content-MD5"))
+
+	// an existing object is only replaced with overwrite
+	_, err = f.Command(ctx, "rename", []string{"c.txt", "b.txt"}, nil)
+	assert.ErrorContains(t, err, "can't rename c.txt to b.txt: it already exists, use -o overwrite to replace it")
+	assert.Equal(t, "world", string(store.objects["b.txt"]))
+	_, err = f.Command(ctx, "rename", []string{"c.txt", "b.txt"}, map[string]string{"overwrite": ""})
+	require.NoError(t, err)
+	assert.NotContains(t, store.objects, "c.txt")
+	assert.Equal(t, "hello", string(store.objects["b.txt"]))
+}
+
+func TestListBucketsCompartment(t *testing.T) {
+	ctx := context.Background()
+	const (
+		configured = "ocid1.compartment.oc1..configured"
+		other      = "ocid1.compartment.oc1..other"
+	)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/n/testns/b" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		name := map[string]string{configured: "configured-bucket", other: "other-bucket"}[r.URL.Query().Get("compartmentId")]
+		_, _ = fmt.Fprintf(w, `[{"namespace":"testns","name":%q,"compartmentId":"x","createdBy":"x","timeCreated":"2022-07-29T06:21:16.595Z","etag":"x"}]`, name)
+	}
+	f, _ := newTestFs(t, "", handler, configmap.Simple{"compartment": configured})
+	// buckets can't be listed without auth
+	f.opt.Provider = userPrincipal
+
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "configured-bucket", entries[0].Remote())
+
+	got, err := f.Command(ctx, "list-buckets", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"configured-bucket"}, got)
+
+	got, err = f.Command(ctx, "list-buckets", nil, map[string]string{"compartment": other})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other-bucket"}, got)
+
+	_, err = f.Command(ctx, "list-buckets", nil, map[string]string{"compartment": "potato"})
+	assert.ErrorContains(t, err, "not a valid compartment")
+}
+
+func TestMultipartHash(t *testing.T) {
+	ctx := context.Background()
+	contents := []byte(random.String(2500))
+	md5sum := fmt.Sprintf("%x", md5.Sum(contents))
+	store := newMemoryStore()
+	f, ts := newTestFs(t, "bucket", store.handler, configmap.Simple{
+		"no_check_bucket": "true",
+		"chunk_size":      "1k",
+		"upload_cutoff":   "2k",
+	})
+	assert.False(t, f.Features().SlowHash)
+	put := func(remote string, contents []byte, hashes map[hash.Type]string) fs.Object {
+		src := object.NewStaticObjectInfo(remote, time.Now(), int64(len(contents)), true, hashes, f)
+		o, err := f.Put(ctx, bytes.NewReader(contents), src)
+		require.NoError(t, err)
+		return o
+	}
+	objects := map[string]fs.Object{
+		"single.txt":       put("single.txt", contents[:1000], nil),
+		"multi.txt":        put("multi.txt", contents, map[hash.Type]string{hash.MD5: md5sum}),
+		"multi-no-md5.txt": put("multi-no-md5.txt", contents, nil),
+	}
+	want := map[string]string{
+		"single.txt":       fmt.Sprintf("%x", md5.Sum(contents[:1000])),
+		"multi.txt":        md5sum,
+		"multi-no-md5.txt": "",
+	}
+	hashes := func(objects map[string]fs.Object) map[string]string {
+		got := map[string]string{}
+		for remote, o := range objects {
+			sum, err := o.Hash(ctx, hash.MD5)
+			require.NoError(t, err)
+			got[remote] = sum
+		}
+		return got
+	}
+
+	// after uploading and when read with a HEAD the MD5 stored in
+	// the metadata is used for multipart objects
+	assert.Equal(t, want, hashes(objects))
+	for remote := range objects {
+		o, err := f.NewObject(ctx, remote)
+		require.NoError(t, err)
+		objects[remote] = o
+	}
+	assert.Equal(t, want, hashes(objects))
+
+	// the listing only has the multipart MD5 which isn't used
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	for _, entry := range entries {
+		objects[entry.Remote()] = entry.(fs.Object)
+	}
+	heads := ts.count(http.MethodHead)
+	want["multi.txt"] = ""
+	assert.Equal(t, want, hashes(objects))
+	assert.Equal(t, heads, ts.count(http.MethodHead))
+}
+
+func TestSetMetadata(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{"no_check_bucket": "true"})
+	for _, remote := range []string{"dir/a.txt", "dir/sub/b.txt", "dir/archived.txt", "other.txt"} {
+		putTestObject(t, f, remote, []byte(remote))
+	}
+	store.meta["dir/a.txt"].Set("storage-tier", "InfrequentAccess")
+	store.meta["dir/a.txt"].Set("Content-Language", "en")
+	store.meta["dir/archived.txt"].Set("storage-tier", "Archive")
+	mtime := store.meta["dir/a.txt"].Get(ociMetaPrefix + metaMtime)
+	require.NotEmpty(t, mtime)
+
+	_, err := f.Command(ctx, "set-metadata", nil, nil)
+	assert.ErrorContains(t, err, "needs some metadata")
+
+	fdir, _ := newTestFs(t, "bucket/dir", store.handler, configmap.Simple{"no_check_bucket": "true"})
+	got, err := fdir.Command(ctx, "set-metadata", nil, map[string]string{
+		"content-type":  "text/html",
+		"cache-control": "max-age=3600",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &setMetadataResult{Updated: 2, Skipped: []string{"archived.txt"}}, got)
+
+	for _, remote := range []string{"dir/a.txt", "dir/sub/b.txt"} {
+		meta := store.meta[remote]
+		assert.Equal(t, "text/html", meta.Get("Content-Type"), remote)
+		assert.Equal(t, "max-age=3600", meta.Get("Cache-Control"), remote)
+		assert.Equal(t, mtime, meta.Get(ociMetaPrefix+metaMtime), remote)
+		// the data is unchanged
+		assert.Equal(t, []byte(remote), store.objects[remote])
+	}
+	// the tier and other content headers are kept
+	assert.Equal(t, "InfrequentAccess", store.meta["dir/a.txt"].Get("storage-tier"))
+	assert.Equal(t, "en", store.meta["dir/a.txt"].Get("Content-Language"))
+	// archived objects and those outside the path are left alone
+	assert.Equal(t, "", store.meta["dir/archived.txt"].Get("Cache-Control"))
+	assert.Equal(t, "", store.meta["other.txt"].Get("Cache-Control"))
+}
+
+func TestListPage(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	f, _ := newTestFs(t, "bucket", store.handler, configmap.Simple{"no_check_bucket": "true"})
+	for _, remote := range []string{"dir/a", "dir/b", "dir/c", "dir/．", "other"} {
+		putTestObject(t, f, remote, []byte(remote))
+	}
+	fdir, _ := newTestFs(t, "bucket/dir", store.handler, nil)
+
+	var names []string
+	page := func(start string) string {
+		got, err := fdir.Command(ctx, "list", nil, map[string]string{"limit": "2", "start": start})
+		require.NoError(t, err)
+		result := got.(*listPage)
+		assert.LessOrEqual(t, len(result.Objects), 2)
+		for _, object := range result.Objects {
+			names = append(names, object.Name)
+			assert.Equal(t, int64(len("dir/"+object.Name)), object.Size)
+		}
+		return result.NextStart
+	}
+	next := page("")
+	assert.Equal(t, "c", next)
+	next = page(next)
+	assert.Equal(t, "", next)
+	// the names are decoded relative to the root
+	assert.Equal(t, []string{"a", "b", "c", "．"}, names)
+
+	// the start is encoded
+	names = nil
+	assert.Equal(t, "", page("．"))
+	assert.Equal(t, []string{"．"}, names)
+
+	_, err := fdir.Command(ctx, "list", nil, map[string]string{"limit": "0"})
+	assert.ErrorContains(t, err, "limit must be a number from 1 to 1000")
+}
+
+func TestListDirectoriesWithDelimiter(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	for i := 0; i < 50; i++ {
+		store.objects[fmt.Sprintf("prefix/dir%d/sub/file%d.txt", i%3, i)] = []byte("hello")
+	}
+	store.objects["prefix/file.txt"] = []byte("hello")
+	var delimiters []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/o") {
+			delimiters = append(delimiters, r.URL.Query().Get("delimiter"))
+		}
+		store.handler(w, r)
+	}
+	f, _ := newTestFs(t, "bucket/prefix", handler, configmap.Simple{"no_check_bucket": "true"})
+
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	// object storage returns the common prefixes rather than every
+	// object under them
+	assert.Equal(t, []string{"/"}, delimiters)
+	var dirs, files []string
+	for _, entry := range entries {
+		if _, ok := entry.(fs.Directory); ok {
+			dirs = append(dirs, entry.Remote())
+		} else {
+			files = append(files, entry.Remote())
+		}
+	}
+	assert.Equal(t, []string{"dir0", "dir1", "dir2"}, dirs)
+	assert.Equal(t, []string{"file.txt"}, files)
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	for _, name := range []string{"dir/", "dir/a.txt", "dir/sub/", "dir/sub/b.txt", "dir/sub/sub2/", "other.txt"} {
+		store.objects[name] = []byte{}
+	}
+	store.objects["dir/a.txt"] = []byte("hello")
+	var deletes []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			store.mu.Lock()
+			deletes = append(deletes, r.URL.Path)
+			store.mu.Unlock()
+			if strings.HasSuffix(r.URL.Path, "/o/dir/sub/sub2/") {
+				// already deleted by someone else
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+		}
+		store.handler(w, r)
+	}
+	f, _ := newTestFs(t, "bucket", handler, configmap.Simple{"no_check_bucket": "true"})
+
+	require.NoError(t, f.Purge(ctx, "dir"))
+	assert.Len(t, deletes, 5)
+	delete(store.objects, "dir/sub/sub2/")
+	assert.Equal(t, map[string][]byte{"other.txt": {}}, store.objects)
+	assert.ErrorIs(t, f.Purge(ctx, "dir"), fs.ErrorDirNotFound)
+
+	// removing a directory deletes its marker
+	store.objects["empty/"] = []byte{}
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	require.NoError(t, f.Rmdir(ctx, "empty"))
+	assert.NotContains(t, store.objects, "empty/")
+	require.NoError(t, f.Rmdir(ctx, "empty"))
+
+	// purging the bucket deletes it too
+	store.objects["dir/"] = []byte{}
+	require.NoError(t, f.Purge(ctx, ""))
+	assert.Empty(t, store.objects)
+	assert.True(t, store.removed)
+}
+
+func TestDirectoryMarkers(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	// a marker made by another tool
+	store.objects["dir/"] = []byte{}
+	store.objects["dir/file.txt"] = []byte("hello")
+	f, ts := newTestFs(t, "bucket", store.handler, configmap.Simple{"no_check_bucket": "true"})
+
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	_, isDir := entries[0].(fs.Directory)
+	assert.True(t, isDir)
+	assert.Equal(t, "dir", entries[0].Remote())
+
+	entries, err = f.List(ctx, "dir")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "dir/file.txt", entries[0].Remote())
+
+	// directories aren't stored so making one does nothing
+	requests := len(ts.requests)
+	require.NoError(t, f.Mkdir(ctx, "dir/new"))
+	assert.Equal(t, requests, len(ts.requests))
+}
@@ -0,0 +1,206 @@
+//go:build !plan9 && !solaris && !js
+// +build !plan9,!solaris,!js
+
+package oracleobjectstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// defaultParExpiry is how long a pre-authenticated request lasts if
+// the expire option isn't given
+const defaultParExpiry = 24 * time.Hour
+
+// parAccessTypes maps the access option of par-create to the access
+// types of object pre-authenticated requests
+var parAccessTypes = map[string]objectstorage.CreatePreauthenticatedRequestDetailsAccessTypeEnum{
+	"read":      objectstorage.CreatePreauthenticatedRequestDetailsAccessTypeObjectread,
+	"write":     objectstorage.CreatePreauthenticatedRequestDetailsAccessTypeObjectwrite,
+	"readwrite": objectstorage.CreatePreauthenticatedRequestDetailsAccessTypeObjectreadwrite,
+}
+
+// parResult is the output of the par-create and par-list backend
+// commands. The URL is only known when the PAR is created.
+type parResult struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Object     string    `json:"object,omitempty"`
+	AccessType string    `json:"accessType"`
+	URL        string    `json:"url,omitempty"`
+	Expires    time.Time `json:"expires"`
+}
+
+// parAccessType returns the access type for the access option of
+// par-create, which defaults to write
+func parAccessType(access string) (objectstorage.CreatePreauthenticatedRequestDetailsAccessTypeEnum, error) {
+	if access == "" {
+		access = "write"
+	}
+	accessType, ok := parAccessTypes[strings.ToLower(access)]
+	if !ok {
+		var valid []string
+		for name := range parAccessTypes {
+			valid = append(valid, name)
+		}
+		sort.Strings(valid)
+		return "", fmt.Errorf("unknown access %q: must be one of %s", access, strings.Join(valid, ", "))
+	}
+	return accessType, nil
+}
+
+// parURL returns the full URL of a pre-authenticated request from the
+// endpoint host and the access URI object storage returns for it
+func parURL(host, accessURI string) string {
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	return strings.TrimSuffix(host, "/") + "/" + strings.TrimPrefix(accessURI, "/")
+}
+
+// createPar creates a pre-authenticated request for bucketPath in
+// bucketName from the options of the par-create backend command
+func (f *Fs) createPar(ctx context.Context, bucketName, bucketPath string, opt map[string]string) (*parResult, error) {
+	if bucketName == "" || bucketPath == "" {
+		return nil, fmt.Errorf("par-create needs an object, eg oos:bucket/path/to/object")
+	}
+	accessType, err := parAccessType(opt["access"])
+	if err != nil {
+		return nil, err
+	}
+	expiry := defaultParExpiry
+	if opt["expire"] != "" {
+		expiry, err = fs.ParseDuration(opt["expire"])
+		if err != nil {
+			return nil, fmt.Errorf("bad expire: %w", err)
+		}
+		if expiry <= 0 {
+			return nil, fmt.Errorf("expire must be positive, got %v", opt["expire"])
+		}
+	}
+	name := opt["name"]
+	if name == "" {
+		name = fmt.Sprintf("rclone-%s-%s", bucketPath, time.Now().UTC().Format("20060102T150405Z"))
+	}
+	req := objectstorage.CreatePreauthenticatedRequestRequest{
+		NamespaceName: common.String(f.opt.Namespace),
+		BucketName:    common.String(bucketName),
+		CreatePreauthenticatedRequestDetails: objectstorage.CreatePreauthenticatedRequestDetails{
+			Name:        common.String(name),
+			ObjectName:  common.String(bucketPath),
+			AccessType:  accessType,
+			TimeExpires: &common.SDKTime{Time: time.Now().Add(expiry)},
+		},
+	}
+	var response objectstorage.CreatePreauthenticatedRequestResponse
+	err = f.pacer.Call(logRetries("CreatePreauthenticatedRequest", func() (bool, error) {
+		reqCtx, cancel := f.requestContext(ctx)
+		defer cancel()
+		var err error
+		response, err = f.srv.CreatePreauthenticatedRequest(reqCtx, req)
+		return shouldRetryNotIdempotent(ctx, response.HTTPResponse(), err)
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pre-authenticated request for %q in bucket %q: %w", bucketPath, bucketName, err)
+	}
+	par := response.PreauthenticatedRequest
+	result := &parResult{
+		ID:         *par.Id,
+		Name:       *par.Name,
+		Object:     bucketPath,
+		AccessType: string(par.AccessType),
+		URL:        parURL(f.srv.Host, *par.AccessUri),
+	}
+	if par.TimeExpires != nil {
+		result.Expires = par.TimeExpires.Time
+	}
+	fs.Infof(f, "Created %s pre-authenticated request %q for %q expiring %s", result.AccessType, result.Name, bucketPath, result.Expires.Format(time.RFC3339))
+	return result, nil
+}
+
+// listPars lists the pre-authenticated requests of bucketName for
+// objects starting with prefix
+func (f *Fs) listPars(ctx context.Context, bucketName, prefix string) (pars []parResult, err error) {
+	pars = []parResult{}
+	req := objectstorage.ListPreauthenticatedRequestsRequest{
+		NamespaceName: common.String(f.opt.Namespace),
+		BucketName:    common.String(bucketName),
+	}
+	if prefix != "" {
+		req.ObjectNamePrefix = common.String(prefix)
+	}
+	var response objectstorage.ListPreauthenticatedRequestsResponse
+	for {
+		err = f.pacer.Call(logRetries("ListPreauthenticatedRequests", func() (bool, error) {
+			reqCtx, cancel := f.requestContext(ctx)
+			defer cancel()
+			response, err = f.srv.ListPreauthenticatedRequests(reqCtx, req)
+			return shouldRetry(ctx, response.HTTPResponse(), err)
+		}))
+		if err != nil {
+			return pars, err
+		}
+		for _, item := range response.Items {
+			par := parResult{
+				ID:         *item.Id,
+				Name:       *item.Name,
+				AccessType: string(item.AccessType),
+			}
+			if item.ObjectName != nil {
+				par.Object = *item.ObjectName
+			}
+			if item.TimeExpires != nil {
+				par.Expires = item.TimeExpires.Time
+			}
+			pars = append(pars, par)
+		}
+		if response.OpcNextPage == nil {
+			break
+		}
+		req.Page = response.OpcNextPage
+	}
+	return pars, nil
+}
+
+// deletePar deletes the pre-authenticated request parID of bucketName.
+//
+// It isn't an error if it doesn't exist, so it can be run again.
+func (f *Fs) deletePar(ctx context.Context, bucketName, parID string) error {
+	if parID == "" {
+		return errors.New("par-delete needs the id of the PAR, eg -o id=PAR_ID")
+	}
+	if operations.SkipDestructive(ctx, parID, "delete pre-authenticated request") {
+		return nil
+	}
+	req := objectstorage.DeletePreauthenticatedRequestRequest{
+		NamespaceName: common.String(f.opt.Namespace),
+		BucketName:    common.String(bucketName),
+		ParId:         common.String(parID),
+	}
+	err := f.pacer.Call(logRetries("DeletePreauthenticatedRequest", func() (bool, error) {
+		reqCtx, cancel := f.requestContext(ctx)
+		defer cancel()
+		response, err := f.srv.DeletePreauthenticatedRequest(reqCtx, req)
+		return shouldRetry(ctx, response.HTTPResponse(), err)
+	}))
+	var serviceErr common.ServiceError
+	if errors.As(err, &serviceErr) && serviceErr.GetHTTPStatusCode() == http.StatusNotFound {
+		fs.Debugf(f, "Pre-authenticated request %q already deleted", parID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete pre-authenticated request %q: %w", parID, err)
+	}
+	fs.Infof(f, "Deleted pre-authenticated request %q", parID)
+	return nil
+}
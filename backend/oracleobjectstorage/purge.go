@@ -0,0 +1,140 @@
+//go:build !plan9 && !solaris && !js
+// +build !plan9,!solaris,!js
+
+package oracleobjectstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/pacer"
+)
+
+// Purge deletes all the objects under dir, --transfers at once, and
+// the bucket too if dir is a bucket.
+//
+// This includes the zero length directory markers made by other tools,
+// such as "dir/", which aren't listed as objects so would otherwise be
+// left behind, keeping the directory, or the bucket, from going.
+//
+// Objects which have already gone aren't an error so this can be run
+// again if it fails part way.
+func (f *Fs) Purge(ctx context.Context, dir string) error {
+	bucketName, directory := f.split(dir)
+	if bucketName == "" {
+		return errors.New("can't purge from root")
+	}
+	prefix := ""
+	if directory != "" {
+		prefix = directory + "/"
+	}
+	var (
+		wg      sync.WaitGroup
+		tokens  = pacer.NewTokenDispenser(f.ci.Transfers)
+		mu      sync.Mutex // protects failed and lastErr
+		failed  int
+		lastErr error
+		deleted int
+	)
+	err := f.listObjectNames(ctx, bucketName, prefix, func(name string) error {
+		deleted++
+		tokens.Get()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer tokens.Put()
+			err := f.deleteObject(ctx, bucketName, name)
+			if err != nil {
+				fs.Errorf(f, "Failed to delete %q: %v", name, err)
+				mu.Lock()
+				failed++
+				lastErr = err
+				mu.Unlock()
+			}
+		}()
+		return nil
+	})
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to delete %d of %d objects: %w", failed, deleted, lastErr)
+	}
+	if directory == "" {
+		return f.Rmdir(ctx, dir)
+	}
+	if deleted == 0 {
+		return fs.ErrorDirNotFound
+	}
+	return nil
+}
+
+// listObjectNames calls fn with the name of every object in bucketName
+// starting with prefix, including directory markers
+func (f *Fs) listObjectNames(ctx context.Context, bucketName, prefix string, fn func(name string) error) error {
+	request := objectstorage.ListObjectsRequest{
+		NamespaceName: common.String(f.opt.Namespace),
+		BucketName:    common.String(bucketName),
+		Prefix:        common.String(prefix),
+		Limit:         common.Int(f.opt.ListChunk),
+		Fields:        common.String("name"),
+	}
+	for {
+		var resp objectstorage.ListObjectsResponse
+		err := f.pacer.Call(logRetries("ListObjects", func() (bool, error) {
+			reqCtx, cancel := f.requestContext(ctx)
+			defer cancel()
+			var err error
+			resp, err = f.srv.ListObjects(reqCtx, request)
+			return shouldRetry(ctx, resp.HTTPResponse(), err)
+		}))
+		var serviceErr common.ServiceError
+		if errors.As(err, &serviceErr) && serviceErr.GetHTTPStatusCode() == http.StatusNotFound && !isWrongHost(err) {
+			return fs.ErrorDirNotFound
+		}
+		if err != nil {
+			return err
+		}
+		for _, object := range resp.Objects {
+			err = fn(*object.Name)
+			if err != nil {
+				return err
+			}
+		}
+		if resp.NextStartWith == nil {
+			return nil
+		}
+		request.Start = resp.NextStartWith
+	}
+}
+
+// deleteObject deletes the object name from bucketName.
+//
+// It isn't an error if it doesn't exist.
+func (f *Fs) deleteObject(ctx context.Context, bucketName, name string) error {
+	f.listCache.forget(bucketName, name)
+	req := objectstorage.DeleteObjectRequest{
+		NamespaceName: common.String(f.opt.Namespace),
+		BucketName:    common.String(bucketName),
+		ObjectName:    common.String(name),
+	}
+	err := f.pacer.Call(logRetries("DeleteObject", func() (bool, error) {
+		reqCtx, cancel := f.requestContext(ctx)
+		defer cancel()
+		resp, err := f.srv.DeleteObject(reqCtx, req)
+		return shouldRetry(ctx, resp.HTTPResponse(), err)
+	}))
+	var serviceErr common.ServiceError
+	if errors.As(err, &serviceErr) && serviceErr.GetHTTPStatusCode() == http.StatusNotFound {
+		fs.Debugf(f, "Object %q already deleted", name)
+		return nil
+	}
+	return err
+}
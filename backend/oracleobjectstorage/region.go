@@ -0,0 +1,134 @@
+//go:build !plan9 && !solaris && !js
+// +build !plan9,!solaris,!js
+
+package oracleobjectstorage
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+)
+
+// second level domain of each realm
+var realmDomains = map[string]string{
+	"oc1":  "oraclecloud.com",
+	"oc2":  "oraclegovcloud.com",
+	"oc3":  "oraclegovcloud.com",
+	"oc4":  "oraclegovcloud.uk",
+	"oc8":  "oraclecloud8.com",
+	"oc9":  "oraclecloud9.com",
+	"oc10": "oraclecloud10.com",
+	"oc14": "oraclecloud14.com",
+}
+
+// realms of regions the SDK doesn't know about yet, by region prefix
+var regionPrefixRealms = []struct {
+	prefix string
+	realm  string
+}{
+	{"us-langley-", "oc2"},
+	{"us-luke-", "oc2"},
+	{"us-gov-", "oc3"},
+	{"uk-gov-", "oc4"},
+}
+
+// regionRealm returns the realm of region, which may be a region
+// identifier such as us-ashburn-1 or a short code such as iad.
+//
+// It returns the canonical region identifier and its realm, which is
+// oc1 for commercial regions which aren't recognised.
+func regionRealm(region string) (string, string) {
+	r := common.StringToRegion(strings.TrimSpace(region))
+	if realm, err := r.RealmID(); err == nil {
+		return string(r), realm
+	}
+	for _, p := range regionPrefixRealms {
+		if strings.HasPrefix(string(r), p.prefix) {
+			return string(r), p.realm
+		}
+	}
+	return string(r), "oc1"
+}
+
+// regionEndpoint returns the default object storage endpoint for
+// region, or "" if region is blank
+func regionEndpoint(region string) string {
+	if strings.TrimSpace(region) == "" {
+		return ""
+	}
+	region, realm := regionRealm(region)
+	domain, ok := realmDomains[realm]
+	if !ok {
+		domain = realmDomains["oc1"]
+	}
+	return "https://objectstorage." + region + "." + domain
+}
+
+// realmSpecificEnvVar is the environment variable the OCI SDKs read to
+// use realm specific endpoints
+const realmSpecificEnvVar = "OCI_REALM_SPECIFIC_SERVICE_ENDPOINT_TEMPLATE_ENABLED"
+
+// second level domain of the realm specific endpoints of each realm
+var realmSpecificDomains = map[string]string{
+	"oc1": "oci.customer-oci.com",
+}
+
+// useRealmSpecificEndpoint returns true if the realm specific endpoint
+// of the namespace should be used, either from the config or the
+// environment variable the OCI SDKs use
+func useRealmSpecificEndpoint(opt *Options) bool {
+	if opt.RealmSpecificEndpoint {
+		return true
+	}
+	enabled, _ := strconv.ParseBool(os.Getenv(realmSpecificEnvVar))
+	return enabled
+}
+
+// realmSpecificEndpoint returns the dedicated object storage endpoint
+// of namespace in region, or "" if it isn't known for the realm
+func realmSpecificEndpoint(namespace, region string) string {
+	if strings.TrimSpace(region) == "" || namespace == "" {
+		return ""
+	}
+	region, realm := regionRealm(region)
+	domain, ok := realmSpecificDomains[realm]
+	if !ok {
+		return ""
+	}
+	return "https://" + strings.ToLower(namespace) + ".objectstorage." + region + "." + domain
+}
+
+// endpointRegionRegexp matches the region in the host name of an
+// object storage endpoint such as objectstorage.us-ashburn-1.oraclecloud.com
+// or the realm specific and private endpoints of a namespace
+var endpointRegionRegexp = regexp.MustCompile(`(?:^|\.)objectstorage\.([a-z0-9-]+)\.`)
+
+// checkEndpointRegion returns an error if endpoint is the object
+// storage endpoint of a region other than region.
+//
+// Endpoints which don't name a region, such as custom domains, and
+// configs without a region aren't checked.
+func checkEndpointRegion(endpoint, region string) error {
+	if strings.TrimSpace(region) == "" {
+		return nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil
+	}
+	match := endpointRegionRegexp.FindStringSubmatch(strings.ToLower(u.Hostname()))
+	if match == nil {
+		return nil
+	}
+	endpointRegion, _ := regionRealm(match[1])
+	region, _ = regionRealm(region)
+	if endpointRegion != region {
+		return fmt.Errorf("endpoint %q is in region %q but region is %q: set them both for the same region, or set no_check_bucket to skip this check", endpoint, endpointRegion, region)
+	}
+	return nil
+}
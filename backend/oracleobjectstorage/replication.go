@@ -0,0 +1,87 @@
+//go:build !plan9 && !solaris && !js
+// +build !plan9,!solaris,!js
+
+package oracleobjectstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// listReplicationPolicies lists the replication policies of bucketName
+func (f *Fs) listReplicationPolicies(ctx context.Context, bucketName string) (policies []objectstorage.ReplicationPolicySummary, err error) {
+	policies = []objectstorage.ReplicationPolicySummary{}
+	req := objectstorage.ListReplicationPoliciesRequest{
+		NamespaceName: common.String(f.opt.Namespace),
+		BucketName:    common.String(bucketName),
+	}
+	var response objectstorage.ListReplicationPoliciesResponse
+	for {
+		err = f.pacer.Call(logRetries("ListReplicationPolicies", func() (bool, error) {
+			reqCtx, cancel := f.requestContext(ctx)
+			defer cancel()
+			response, err = f.srv.ListReplicationPolicies(reqCtx, req)
+			return shouldRetry(ctx, response.HTTPResponse(), err)
+		}))
+		if err != nil {
+			return policies, err
+		}
+		policies = append(policies, response.Items...)
+		if response.OpcNextPage == nil {
+			break
+		}
+		req.Page = response.OpcNextPage
+	}
+	return policies, nil
+}
+
+// createReplicationPolicy creates a replication policy on bucketName
+// from the options of the replication backend command.
+//
+// The destination bucket is made read only by object storage so this
+// needs the confirm option.
+func (f *Fs) createReplicationPolicy(ctx context.Context, bucketName string, opt map[string]string) (*objectstorage.ReplicationPolicy, error) {
+	destinationRegion, destinationBucket := opt["destination-region"], opt["destination-bucket"]
+	if destinationRegion == "" || destinationBucket == "" {
+		return nil, errors.New("replication create needs -o destination-region=REGION -o destination-bucket=BUCKET")
+	}
+	if _, ok := opt["confirm"]; !ok {
+		return nil, fmt.Errorf("replication to %s/%s makes the destination bucket read only, add -o confirm to create it", destinationRegion, destinationBucket)
+	}
+	name := opt["name"]
+	if name == "" {
+		name = fmt.Sprintf("%s-to-%s-%s", bucketName, destinationRegion, destinationBucket)
+	}
+	what := fmt.Sprintf("replication policy %q from %s to %s/%s", name, bucketName, destinationRegion, destinationBucket)
+	if operations.SkipDestructive(ctx, what, "create replication policy") {
+		return nil, nil
+	}
+	req := objectstorage.CreateReplicationPolicyRequest{
+		NamespaceName: common.String(f.opt.Namespace),
+		BucketName:    common.String(bucketName),
+		CreateReplicationPolicyDetails: objectstorage.CreateReplicationPolicyDetails{
+			Name:                  common.String(name),
+			DestinationRegionName: common.String(destinationRegion),
+			DestinationBucketName: common.String(destinationBucket),
+		},
+	}
+	var response objectstorage.CreateReplicationPolicyResponse
+	err := f.pacer.Call(logRetries("CreateReplicationPolicy", func() (bool, error) {
+		reqCtx, cancel := f.requestContext(ctx)
+		defer cancel()
+		var err error
+		response, err = f.srv.CreateReplicationPolicy(reqCtx, req)
+		return shouldRetryNotIdempotent(ctx, response.HTTPResponse(), err)
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", what, err)
+	}
+	fs.Infof(f, "Created %s", what)
+	return &response.ReplicationPolicy, nil
+}
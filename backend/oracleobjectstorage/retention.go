@@ -0,0 +1,218 @@
+//go:build !plan9 && !solaris && !js
+// +build !plan9,!solaris,!js
+
+package oracleobjectstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	"github.com/rclone/rclone/fs"
+)
+
+// isRetentionError returns true if err is the conflict OCI returns
+// when an object is protected from deletion or overwrite by one of the
+// bucket's retention rules
+func isRetentionError(err error) bool {
+	var serviceErr common.ServiceError
+	if !errors.As(err, &serviceErr) {
+		return false
+	}
+	return serviceErr.GetHTTPStatusCode() == http.StatusConflict &&
+		strings.Contains(strings.ToLower(serviceErr.GetMessage()), "retention")
+}
+
+// retentionUntil returns the time until which an object last modified
+// at modTime is retained by rules, or indefinite if any rule has no
+// duration
+func retentionUntil(rules []objectstorage.RetentionRuleSummary, modTime time.Time) (until time.Time, indefinite bool) {
+	for _, rule := range rules {
+		if rule.Duration == nil || rule.Duration.TimeAmount == nil {
+			return time.Time{}, true
+		}
+		amount := int(*rule.Duration.TimeAmount)
+		var t time.Time
+		switch rule.Duration.TimeUnit {
+		case objectstorage.DurationTimeUnitYears:
+			t = modTime.AddDate(amount, 0, 0)
+		default:
+			t = modTime.AddDate(0, 0, amount)
+		}
+		if t.After(until) {
+			until = t
+		}
+	}
+	return until, false
+}
+
+// retentionError turns a retention rule conflict returned by OCI into
+// an error saying how long the object is retained for. Other errors
+// are returned unchanged.
+func (o *Object) retentionError(ctx context.Context, err error) error {
+	if !isRetentionError(err) {
+		return err
+	}
+	bucketName, _ := o.split()
+	rules, listErr := o.fs.listRetentionRules(ctx, bucketName)
+	if listErr != nil || len(rules) == 0 || o.lastModified.IsZero() {
+		if listErr != nil {
+			fs.Debugf(o, "failed to list retention rules: %v", listErr)
+		}
+		return fmt.Errorf("object is protected by a retention rule on bucket %q: %w", bucketName, err)
+	}
+	until, indefinite := retentionUntil(rules, o.lastModified)
+	if indefinite {
+		return fmt.Errorf("object is under an indefinite retention rule on bucket %q: %w", bucketName, err)
+	}
+	return fmt.Errorf("object is under retention until %s: %w", until.UTC().Format(time.RFC3339), err)
+}
+
+// listRetentionRules lists all the retention rules on the bucket
+func (f *Fs) listRetentionRules(ctx context.Context, bucketName string) (rules []objectstorage.RetentionRuleSummary, err error) {
+	rules = []objectstorage.RetentionRuleSummary{}
+	req := objectstorage.ListRetentionRulesRequest{
+		NamespaceName: common.String(f.opt.Namespace),
+		BucketName:    common.String(bucketName),
+	}
+	var response objectstorage.ListRetentionRulesResponse
+	for {
+		err = f.pacer.Call(logRetries("ListRetentionRules", func() (bool, error) {
+			reqCtx, cancel := f.requestContext(ctx)
+			defer cancel()
+			response, err = f.srv.ListRetentionRules(reqCtx, req)
+			return shouldRetry(ctx, response.HTTPResponse(), err)
+		}))
+		if err != nil {
+			return rules, err
+		}
+		rules = append(rules, response.Items...)
+		if response.OpcNextPage == nil {
+			break
+		}
+		req.Page = response.OpcNextPage
+	}
+	return rules, nil
+}
+
+// metaRetentionUntil is the metadata key of the time until which an
+// object is retained by the retention rules of its bucket
+const metaRetentionUntil = "retention-until"
+
+// retentionMetadata returns the value of the retention-until metadata
+// of o: the time until which it is retained, "indefinite" if a rule
+// without a duration holds it or "" if it isn't retained.
+//
+// Object storage doesn't return the retention of each object so this
+// is worked out from the retention rules of the bucket, which apply to
+// objects from when they were last modified.
+func (o *Object) retentionMetadata(ctx context.Context) string {
+	bucketName, _ := o.split()
+	rules := o.fs.bucketRetentionRules(ctx, bucketName)
+	if len(rules) == 0 || o.lastModified.IsZero() {
+		return ""
+	}
+	until, indefinite := retentionUntil(rules, o.lastModified)
+	if indefinite {
+		return "indefinite"
+	}
+	if !until.After(time.Now()) {
+		return ""
+	}
+	return until.UTC().Format(time.RFC3339)
+}
+
+// retentionCache holds the retention rules of buckets once read
+type retentionCache struct {
+	mu    sync.Mutex
+	rules map[string][]objectstorage.RetentionRuleSummary // by bucket
+}
+
+// bucketRetentionRules returns the retention rules of bucketName,
+// listing them only the first time they are needed.
+//
+// If they can't be read, for example for lack of permission, it
+// returns none.
+func (f *Fs) bucketRetentionRules(ctx context.Context, bucketName string) []objectstorage.RetentionRuleSummary {
+	f.retention.mu.Lock()
+	defer f.retention.mu.Unlock()
+	if rules, ok := f.retention.rules[bucketName]; ok {
+		return rules
+	}
+	rules, err := f.listRetentionRules(ctx, bucketName)
+	if err != nil {
+		fs.Debugf(f, "Failed to read the retention rules of bucket %q: %v", bucketName, err)
+		rules = nil
+	}
+	if f.retention.rules == nil {
+		f.retention.rules = map[string][]objectstorage.RetentionRuleSummary{}
+	}
+	f.retention.rules[bucketName] = rules
+	return rules
+}
+
+// legalHoldState is the output of the legal-hold backend command
+type legalHoldState struct {
+	Bucket        string     `json:"bucket"`
+	Object        string     `json:"object"`
+	LegalHold     bool       `json:"legalHold"`
+	HoldRules     []string   `json:"holdRules,omitempty"`
+	RetainedUntil *time.Time `json:"retainedUntil,omitempty"`
+}
+
+// errNoObjectLegalHold is returned when asked to set or clear the legal
+// hold of a single object as object storage only has retention rules
+// which apply to the whole bucket
+var errNoObjectLegalHold = fmt.Errorf("%w: OCI Object Storage has no legal hold on single objects, "+
+	"add a retention rule without a duration to the bucket to hold all its objects", fs.ErrorNotImplemented)
+
+// legalHold shows the legal hold state of the object at remote from
+// the retention rules of its bucket.
+//
+// Object storage holds every object in a bucket indefinitely while the
+// bucket has a retention rule without a duration, which is how it
+// implements legal holds, so these can't be set or cleared per object.
+func (f *Fs) legalHold(ctx context.Context, remote string, opt map[string]string) (*legalHoldState, error) {
+	_, on := opt["on"]
+	_, off := opt["off"]
+	if on || off {
+		return nil, errNoObjectLegalHold
+	}
+	bucketName, bucketPath := f.split(remote)
+	if bucketName == "" || bucketPath == "" {
+		return nil, errors.New("legal-hold needs an object, eg oos:bucket path/to/object")
+	}
+	obj, err := f.NewObject(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := f.listRetentionRules(ctx, bucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the retention rules of bucket %q: %w", bucketName, err)
+	}
+	state := &legalHoldState{
+		Bucket: bucketName,
+		Object: bucketPath,
+	}
+	for _, rule := range rules {
+		if rule.Duration == nil || rule.Duration.TimeAmount == nil {
+			state.LegalHold = true
+			if rule.DisplayName != nil {
+				state.HoldRules = append(state.HoldRules, *rule.DisplayName)
+			}
+		}
+	}
+	if !state.LegalHold && len(rules) > 0 {
+		until, _ := retentionUntil(rules, obj.(*Object).lastModified)
+		if until.After(time.Now()) {
+			state.RetainedUntil = &until
+		}
+	}
+	return state, nil
+}
@@ -34,6 +34,7 @@ type StateChangeConf struct {
 	Target         []string         // Target state
 	Timeout        time.Duration    // The amount of time to wait before timeout
 	MinTimeout     time.Duration    // Smallest time to wait before refreshes
+	MaxTimeout     time.Duration    // Largest time to wait before refreshes - defaults to 10s
 	PollInterval   time.Duration    // Override MinTimeout/backoff and only poll this often
 	NotFoundChecks int              // Number of times to allow not found (nil result from Refresh)
 
@@ -72,6 +73,10 @@ func (conf *StateChangeConf) WaitForStateContext(ctx context.Context, entityType
 		conf.ContinuousTargetOccurrence = 1
 	}
 
+	if conf.MaxTimeout == 0 {
+		conf.MaxTimeout = 10 * time.Second
+	}
+
 	type Result struct {
 		Result interface{}
 		State  string
@@ -198,8 +203,8 @@ func (conf *StateChangeConf) WaitForStateContext(ctx context.Context, entityType
 			} else {
 				if wait < conf.MinTimeout {
 					wait = conf.MinTimeout
-				} else if wait > 10*time.Second {
-					wait = 10 * time.Second
+				} else if wait > conf.MaxTimeout {
+					wait = conf.MaxTimeout
 				}
 			}
 